@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+// Caps the rows and columns of the cost matrix; the Hungarian algorithm
+// takes O(n³) steps.
+const maxAssignSize = 300
+
+// A solution of the assignment problem. The costs are strings so that
+// JSON clients don't lose precision.
+type assignment struct {
+	Pairs []assignedPair `json:"pairs"`
+	Total string         `json:"total"`
+}
+
+// A row assigned to a column, along with the cost of the pairing.
+type assignedPair struct {
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+	Cost string `json:"cost"`
+}
+
+// Handles assign requests by validating the supplied cost matrix of
+// int literals and returning the perfect assignment of rows to columns
+// with the minimum total cost (or maximum with `maximize=true`) as
+// JSON. Rows and columns are numbered from 1. Uses the O(n³) Hungarian
+// algorithm, so the matrix is limited to maxAssignSize x maxAssignSize.
+// Expects the matrix CSV in the request context.
+func handleAssign(w h.ResponseWriter, r *h.Request) {
+	maximize := r.FormValue("maximize") == "true"
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(m) > maxAssignSize {
+		h.Error(w, fmt.Sprintf("Error: the cost matrix is limited to %dx%d", maxAssignSize, maxAssignSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	cost := m
+	if maximize {
+		cost = make([][]*big.Int, len(m))
+		for i, row := range m {
+			cost[i] = make([]*big.Int, len(row))
+			for j, d := range row {
+				cost[i][j] = new(big.Int).Neg(d)
+			}
+		}
+	}
+
+	res := assignment{Pairs: []assignedPair{}}
+	total := new(big.Int)
+	for i, j := range hungarian(cost) {
+		res.Pairs = append(res.Pairs, assignedPair{i + 1, j + 1, m[i][j].String()})
+		total.Add(total, m[i][j])
+	}
+	res.Total = total.String()
+
+	writeJSON(w, res)
+}
+
+// Solves the assignment problem for the square cost matrix `a` with
+// the Hungarian algorithm (in its shortest augmenting path form).
+// Returns the column assigned to each row.
+func hungarian(a [][]*big.Int) []int {
+	n := len(a)
+
+	// Row and column potentials; the arrays are 1-based with column 0
+	// as the sentinel that the augmenting paths start from.
+	u, v := make([]*big.Int, n+1), make([]*big.Int, n+1)
+	for i := range u {
+		u[i], v[i] = new(big.Int), new(big.Int)
+	}
+	// The row matched to each column (0 if none) and the previous
+	// column on the augmenting path.
+	p, way := make([]int, n+1), make([]int, n+1)
+
+	cur := new(big.Int)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+
+		// The smallest reduced cost reaching each column; nil stands
+		// for infinity.
+		minv := make([]*big.Int, n+1)
+		used := make([]bool, n+1)
+
+		for p[j0] != 0 {
+			used[j0] = true
+			i0 := p[j0]
+
+			var delta *big.Int
+			j1 := 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+
+				cur.Sub(a[i0-1][j-1], u[i0])
+				cur.Sub(cur, v[j])
+				if minv[j] == nil || cur.Cmp(minv[j]) < 0 {
+					minv[j] = new(big.Int).Set(cur)
+					way[j] = j0
+				}
+				if delta == nil || minv[j].Cmp(delta) < 0 {
+					delta, j1 = minv[j], j
+				}
+			}
+
+			delta = new(big.Int).Set(delta)
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]].Add(u[p[j]], delta)
+					v[j].Sub(v[j], delta)
+				} else {
+					minv[j].Sub(minv[j], delta)
+				}
+			}
+
+			j0 = j1
+		}
+
+		// Flip the augmenting path.
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	cols := make([]int, n)
+	for j := 1; j <= n; j++ {
+		cols[p[j]-1] = j - 1
+	}
+
+	return cols
+}
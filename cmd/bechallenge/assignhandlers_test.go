@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleAssign(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"minimize",
+			"",
+			[]byte("4,1,3\n2,0,5\n3,2,2"),
+			`{"pairs":[{"row":1,"col":2,"cost":"1"},{"row":2,"col":1,"cost":"2"},` +
+				`{"row":3,"col":3,"cost":"2"}],"total":"5"}` + "\n",
+			200,
+		},
+		{
+			"maximize",
+			"maximize=true",
+			[]byte("4,1,3\n2,0,5\n3,2,2"),
+			`{"pairs":[{"row":1,"col":1,"cost":"4"},{"row":2,"col":3,"cost":"5"},` +
+				`{"row":3,"col":2,"cost":"2"}],"total":"11"}` + "\n",
+			200,
+		},
+		{
+			"negative-and-large-costs",
+			"",
+			[]byte("12345678901234567890,-1\n-1,12345678901234567890"),
+			`{"pairs":[{"row":1,"col":2,"cost":"-1"},{"row":2,"col":1,"cost":"-1"}],"total":"-2"}` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			`{"pairs":[],"total":"0"}` + "\n",
+			200,
+		},
+		{
+			"too-large",
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxAssignSize)+"1\n", maxAssignSize+1)),
+			"Error: the cost matrix is limited to 300x300\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := webApiMiddleware(handleAssign)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	h "net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Caps the number of generations of an automaton request.
+	maxAutomatonSteps = 10000
+
+	// Caps the number of cell updates of an automaton request, the
+	// number of cells times the number of generations.
+	maxAutomatonWork = 1 << 26
+)
+
+// A Life-like cellular automaton rule: the numbers of live neighbours
+// that make a dead cell come alive and keep a live cell alive.
+type lifeRule struct {
+	born, survive [9]bool
+}
+
+// Handles automaton requests by validating the supplied matrix of 0/1
+// int literals and returning the grid of cells it describes (1 is
+// alive) after `steps` generations (1 by default) of the Life-like
+// automaton set by the `rule` query parameter, in B/S notation
+// (B3/S23, Conway's Game of Life, by default). The `boundary` query
+// parameter selects whether the cells outside the grid are dead (the
+// default), or whether the grid wraps around like a torus. With
+// `all=true` every generation from the first to the last is streamed,
+// each followed by an empty line. The number of cells times `steps` is
+// limited to maxAutomatonWork. Accepts rectangular grids. Expects the
+// matrix CSV in the request context.
+func handleAutomaton(w h.ResponseWriter, r *h.Request) {
+	s := r.FormValue("rule")
+	if s == "" {
+		s = "B3/S23"
+	}
+	rule, err := parseLifeRule(s)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	steps := 1
+	if s := r.FormValue("steps"); s != "" {
+		if steps, err = strconv.Atoi(s); err != nil || steps < 0 || steps > maxAutomatonSteps {
+			h.Error(w, fmt.Sprintf("Error: steps must be an integer between 0 and %d", maxAutomatonSteps),
+				h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	boundary := r.FormValue("boundary")
+	if boundary == "" {
+		boundary = "dead"
+	}
+	if boundary != "dead" && boundary != "wrap" {
+		h.Error(w, "Error: boundary must be one of dead or wrap", h.StatusBadRequest)
+
+		return
+	}
+
+	all := r.FormValue("all") == "true"
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(m)*ncols(m)*steps > maxAutomatonWork {
+		h.Error(w, fmt.Sprintf("Error: the grid is too large for %d steps", steps), h.StatusBadRequest)
+
+		return
+	}
+
+	grid := make([][]bool, len(m))
+	for i, row := range m {
+		grid[i] = make([]bool, len(row))
+		for j, d := range row {
+			if !d.IsInt64() || d.Int64() != 0 && d.Int64() != 1 {
+				h.Error(w, fmt.Sprintf("Error: cells must be 0 or 1 (row %d, col %d)", i+1, j+1),
+					h.StatusBadRequest)
+
+				return
+			}
+			grid[i][j] = d.Int64() == 1
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for k := 1; k <= steps; k++ {
+		grid = rule.step(grid, boundary == "wrap")
+
+		if all {
+			bw.WriteString(gridtos(grid) + "\n")
+			if err := bw.Flush(); err != nil {
+				l.Error("writing response", "err", err)
+
+				return
+			}
+		}
+	}
+
+	if !all {
+		bw.WriteString(gridtos(grid))
+	} else if steps == 0 {
+		// The challenge spec requires a trailing "\n" in the response.
+		bw.WriteString("\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		l.Error("writing response", "err", err)
+	}
+}
+
+// Parses the Life-like rule `s` in B/S notation, like B36/S23. The
+// parts may come in either order, and the letters in either case.
+func parseLifeRule(s string) (lifeRule, error) {
+	var rule lifeRule
+
+	parts := strings.Split(strings.ToUpper(s), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0][0] == parts[1][0] {
+		return rule, fmt.Errorf("rule %q must be of the form B<digits>/S<digits>", s)
+	}
+
+	for _, p := range parts {
+		var counts *[9]bool
+		switch p[0] {
+		case 'B':
+			counts = &rule.born
+		case 'S':
+			counts = &rule.survive
+		default:
+			return rule, fmt.Errorf("rule %q must be of the form B<digits>/S<digits>", s)
+		}
+
+		for _, c := range p[1:] {
+			if c < '0' || c > '8' {
+				return rule, fmt.Errorf("rule %q: neighbour counts must be digits from 0 to 8", s)
+			}
+			counts[c-'0'] = true
+		}
+	}
+
+	return rule, nil
+}
+
+// Returns the next generation of `grid` under the rule. Cells outside
+// the grid are dead unless `wrap` is set.
+func (rule lifeRule) step(grid [][]bool, wrap bool) [][]bool {
+	rows, cols := len(grid), ncols(grid)
+
+	next := make([][]bool, rows)
+	for i := range next {
+		next[i] = make([]bool, cols)
+
+		for j := range next[i] {
+			var alive int
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					if di == 0 && dj == 0 {
+						continue
+					}
+
+					ni, nj := i+di, j+dj
+					if wrap {
+						ni, nj = (ni+rows)%rows, (nj+cols)%cols
+					} else if ni < 0 || ni >= rows || nj < 0 || nj >= cols {
+						continue
+					}
+
+					if grid[ni][nj] {
+						alive++
+					}
+				}
+			}
+
+			if grid[i][j] {
+				next[i][j] = rule.survive[alive]
+			} else {
+				next[i][j] = rule.born[alive]
+			}
+		}
+	}
+
+	return next
+}
+
+// Converts the grid of cells to a string of CSV rows of 0/1 (see
+// mtos).
+func gridtos(grid [][]bool) string {
+	var sb strings.Builder
+
+	for _, row := range grid {
+		for j, alive := range row {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			if alive {
+				sb.WriteByte('1')
+			} else {
+				sb.WriteByte('0')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if sb.Len() == 0 {
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
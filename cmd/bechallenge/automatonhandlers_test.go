@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleAutomaton(t *testing.T) {
+	blinker := []byte("0,1,0\n0,1,0\n0,1,0")
+	glider := []byte("0,1,0,0\n0,0,1,0\n1,1,1,0\n0,0,0,0")
+	torus := []byte("0,1,0,0,0,0\n0,0,1,0,0,0\n1,1,1,0,0,0\n0,0,0,0,0,0\n0,0,0,0,0,0\n0,0,0,0,0,0")
+
+	tests := []queryTestCase{
+		{
+			"blinker",
+			"",
+			blinker,
+			"0,0,0\n1,1,1\n0,0,0\n",
+			200,
+		},
+		{
+			"blinker-period",
+			"steps=2",
+			blinker,
+			"0,1,0\n0,1,0\n0,1,0\n",
+			200,
+		},
+		{
+			"blinker-all",
+			"steps=2&all=true",
+			blinker,
+			"0,0,0\n1,1,1\n0,0,0\n\n0,1,0\n0,1,0\n0,1,0\n\n",
+			200,
+		},
+		{
+			"glider-wrap",
+			"steps=4&boundary=wrap",
+			torus,
+			"0,0,0,0,0,0\n0,0,1,0,0,0\n0,0,0,1,0,0\n0,1,1,1,0,0\n0,0,0,0,0,0\n0,0,0,0,0,0\n",
+			200,
+		},
+		{
+			"glider-wrap-full-cycle",
+			"steps=24&boundary=wrap",
+			torus,
+			string(torus) + "\n",
+			200,
+		},
+		{
+			"glider-dead-boundary",
+			"steps=8",
+			glider,
+			"0,0,0,0\n0,0,0,0\n0,0,1,1\n0,0,1,1\n",
+			200,
+		},
+		{
+			"custom-rule",
+			"rule=s/b1",
+			[]byte("0,0,0\n0,1,0\n0,0,0"),
+			"1,1,1\n1,0,1\n1,1,1\n",
+			200,
+		},
+		{
+			"rectangular",
+			"steps=0",
+			[]byte("1,0,1,1"),
+			"1,0,1,1\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"invalid-rule",
+			"rule=B3S23",
+			blinker,
+			"Error: rule \"B3S23\" must be of the form B<digits>/S<digits>\n",
+			400,
+		},
+		{
+			"invalid-rule-count",
+			"rule=B9/S23",
+			blinker,
+			"Error: rule \"B9/S23\": neighbour counts must be digits from 0 to 8\n",
+			400,
+		},
+		{
+			"invalid-steps",
+			"steps=10001",
+			blinker,
+			"Error: steps must be an integer between 0 and 10000\n",
+			400,
+		},
+		{
+			"too-much-work",
+			"steps=10000",
+			[]byte(strings.Repeat("0,", 6800) + "0"),
+			"Error: the grid is too large for 10000 steps\n",
+			400,
+		},
+		{
+			"invalid-boundary",
+			"boundary=mirror",
+			blinker,
+			"Error: boundary must be one of dead or wrap\n",
+			400,
+		},
+		{
+			"invalid-cell",
+			"",
+			[]byte("0,1\n2,0"),
+			"Error: cells must be 0 or 1 (row 2, col 1)\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleAutomaton)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+const (
+	// The default damping factor and convergence tolerance of PageRank,
+	// and the default attenuation factor of Katz centrality.
+	defaultDamping = 0.85
+	defaultTol     = 1e-12
+	defaultAlpha   = 0.1
+
+	// Caps the number of power iterations.
+	maxCentralityIterations = 10000
+
+	// Caps the significant digits and the number of nodes of the
+	// measures computed with power iterations, each of which takes
+	// O(n²) big.Float operations.
+	maxCentralityPrec  = 30
+	maxCentralityNodes = 100
+)
+
+// The centrality scores of the nodes of a graph, along with the number
+// of power iterations it took to compute them.
+type centrality struct {
+	Iterations int         `json:"iterations"`
+	Scores     []nodeScore `json:"scores"`
+}
+
+// The score of a node, labeled from the header row or numbered from 1.
+type nodeScore struct {
+	Node  string `json:"node"`
+	Score string `json:"score"`
+}
+
+// Handles pagerank requests by validating the supplied adjacency
+// matrix of non-negative int literals (see handleShortestPaths) and
+// returning the PageRank of its nodes as JSON (see centrality). The
+// edges out of each node share its rank in proportion to their
+// weights; nodes without any spread theirs evenly over the graph.
+//
+// Query parameters:
+//   - damping: the probability of following an edge (0.85 by default)
+//   - tol: the iterations stop once the ranks change by less than this
+//     in total (1e-12 by default)
+//   - prec: the number of significant digits (20 by default, and at
+//     most maxCentralityPrec)
+//   - header: if true, the first row of the CSV holds the node labels
+//
+// The graph is limited to maxCentralityNodes nodes. Expects the matrix
+// CSV in the request context.
+func handlePageRank(w h.ResponseWriter, r *h.Request) {
+	prec, err := parseCentralityPrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+	bits := precBits(prec)
+
+	damping, err := parseFloatParam(r, "damping", defaultDamping, bits)
+	if err != nil || damping.Sign() < 0 || damping.Cmp(newFloat(bits, 1)) > 0 {
+		h.Error(w, "Error: damping must be a number between 0 and 1", h.StatusBadRequest)
+
+		return
+	}
+
+	tol, ok := parseTol(w, r, bits)
+	if !ok {
+		return
+	}
+
+	labels, m, ok := parseLabeledMatrix(w, r)
+	if !ok || !checkCentralityNodes(w, m) || !checkNonNegative(w, m) {
+		return
+	}
+
+	n := len(m)
+	if n == 0 {
+		writeCentrality(w, labels, nil, 0, prec)
+
+		return
+	}
+
+	a := floatMatrix(ratMatrix(m), bits)
+
+	// The total weight of the edges out of each node.
+	out := make([]*big.Float, n)
+	for i, row := range a {
+		out[i] = newFloat(bits, 0)
+		for _, x := range row {
+			out[i].Add(out[i], x)
+		}
+	}
+
+	fn := newFloat(bits, float64(n))
+	teleport := newFloat(bits, 1)
+	teleport.Sub(teleport, damping).Quo(teleport, fn)
+
+	x := make([]*big.Float, n)
+	for i := range x {
+		x[i] = newFloat(bits, 1)
+		x[i].Quo(x[i], fn)
+	}
+
+	iterations, ok := powerIterate(w, x, tol, bits, func(x []*big.Float) []*big.Float {
+		// The rank of the dangling nodes is spread evenly.
+		dangling := newFloat(bits, 0)
+		for i, o := range out {
+			if o.Sign() == 0 {
+				dangling.Add(dangling, x[i])
+			}
+		}
+		dangling.Quo(dangling, fn)
+
+		next := make([]*big.Float, n)
+		for j := range next {
+			next[j] = newFloat(bits, 0).Set(dangling)
+		}
+
+		t := newFloat(bits, 0)
+		for i, row := range a {
+			if out[i].Sign() == 0 {
+				continue
+			}
+			for j, wt := range row {
+				if wt.Sign() != 0 {
+					next[j].Add(next[j], t.Mul(x[i], wt).Quo(t, out[i]))
+				}
+			}
+		}
+
+		for j := range next {
+			next[j].Mul(next[j], damping).Add(next[j], teleport)
+		}
+
+		return next
+	})
+	if !ok {
+		return
+	}
+
+	writeCentrality(w, labels, x, iterations, prec)
+}
+
+// Handles centrality requests by validating the supplied adjacency
+// matrix of int literals (see handleShortestPaths) and returning the
+// centrality of its nodes as JSON (see centrality). The `type` query
+// parameter selects the measure, each based on the edges into a node:
+//   - degree: the number of edges into the node (loops aside) over n-1
+//   - eigenvector: the entries of the principal eigenvector of the
+//     transposed adjacency matrix, with unit Euclidean norm; the
+//     weights must be non-negative
+//   - katz: xᵢ = α·Σⱼ aⱼᵢ·xⱼ + 1, with α set by the `alpha` query
+//     parameter (0.1 by default); it must be less than the reciprocal
+//     of the largest weighted in-degree, which bounds the spectral
+//     radius, so that the iterations converge
+//
+// The `tol`, `prec` and `header` query parameters work as for
+// handlePageRank, as do the limits, which the degree centrality is
+// exempt from. Expects the matrix CSV in the request context.
+func handleCentrality(w h.ResponseWriter, r *h.Request) {
+	typ := r.FormValue("type")
+	if typ != "degree" && typ != "eigenvector" && typ != "katz" {
+		h.Error(w, "Error: type must be one of degree, eigenvector or katz", h.StatusBadRequest)
+
+		return
+	}
+
+	var prec int
+	var err error
+	if typ == "degree" {
+		prec, err = parsePrec(r)
+	} else {
+		prec, err = parseCentralityPrec(r)
+	}
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+	bits := precBits(prec)
+
+	alpha, err := parseFloatParam(r, "alpha", defaultAlpha, bits)
+	if err != nil || alpha.Sign() <= 0 {
+		h.Error(w, "Error: alpha must be a positive number", h.StatusBadRequest)
+
+		return
+	}
+
+	tol, ok := parseTol(w, r, bits)
+	if !ok {
+		return
+	}
+
+	labels, m, ok := parseLabeledMatrix(w, r)
+	if !ok || typ != "degree" && !checkCentralityNodes(w, m) {
+		return
+	}
+
+	n := len(m)
+	a := floatMatrix(ratMatrix(m), bits)
+
+	x := make([]*big.Float, n)
+	iterations := 0
+
+	switch typ {
+	case "degree":
+		for j := range x {
+			var deg int
+			for i := range m {
+				if i != j && m[i][j].Sign() != 0 {
+					deg++
+				}
+			}
+
+			x[j] = newFloat(bits, float64(deg))
+			if n > 1 {
+				x[j].Quo(x[j], newFloat(bits, float64(n-1)))
+			}
+		}
+
+	case "eigenvector":
+		if !checkNonNegative(w, m) {
+			return
+		}
+
+		for i := range x {
+			x[i] = newFloat(bits, 1)
+		}
+
+		// Iterating with A + I rather than A has the same fixed point,
+		// but also converges on periodic graphs.
+		if iterations, ok = powerIterate(w, x, tol, bits, func(x []*big.Float) []*big.Float {
+			next := mulTransposed(a, x, bits)
+
+			norm := newFloat(bits, 0)
+			for i := range next {
+				next[i].Add(next[i], x[i])
+				norm.Add(norm, new(big.Float).Mul(next[i], next[i]))
+			}
+			norm.Sqrt(norm)
+
+			for i := range next {
+				next[i].Quo(next[i], norm)
+			}
+
+			return next
+		}); !ok {
+			return
+		}
+
+	case "katz":
+		if deg := maxInDegree(m); deg.Sign() > 0 &&
+			new(big.Float).Mul(alpha, new(big.Float).SetInt(deg)).Cmp(newFloat(bits, 1)) >= 0 {
+			h.Error(w, fmt.Sprintf("Error: alpha must be less than 1/%s", deg), h.StatusBadRequest)
+
+			return
+		}
+
+		for i := range x {
+			x[i] = newFloat(bits, 0)
+		}
+
+		if iterations, ok = powerIterate(w, x, tol, bits, func(x []*big.Float) []*big.Float {
+			next := mulTransposed(a, x, bits)
+			for i := range next {
+				next[i].Mul(next[i], alpha).Add(next[i], newFloat(bits, 1))
+			}
+
+			return next
+		}); !ok {
+			return
+		}
+	}
+
+	writeCentrality(w, labels, x, iterations, prec)
+}
+
+// Like parsePrec, but limited to maxCentralityPrec digits.
+func parseCentralityPrec(r *h.Request) (int, error) {
+	prec, err := parsePrec(r)
+	if err != nil || prec > maxCentralityPrec {
+		return 0, fmt.Errorf("prec must be an integer between 1 and %d", maxCentralityPrec)
+	}
+
+	return prec, nil
+}
+
+// Rejects graphs with more than maxCentralityNodes nodes. Reports
+// errors to the user; the caller should return if the result is false.
+func checkCentralityNodes(w h.ResponseWriter, m [][]*big.Int) bool {
+	if len(m) > maxCentralityNodes {
+		h.Error(w, fmt.Sprintf("Error: the graph is limited to %d nodes", maxCentralityNodes), h.StatusBadRequest)
+
+		return false
+	}
+
+	return true
+}
+
+// Gets the float query parameter `name`, or `def` if it's missing, at
+// `bits` bits of precision. Rejects infinities.
+func parseFloatParam(r *h.Request, name string, def float64, bits uint) (*big.Float, error) {
+	s := r.FormValue(name)
+	if s == "" {
+		return newFloat(bits, def), nil
+	}
+
+	f, _, err := new(big.Float).SetPrec(bits).Parse(s, 10)
+	if err == nil && f.IsInf() {
+		return nil, fmt.Errorf("%s must be finite", name)
+	}
+
+	return f, err
+}
+
+// Gets the convergence tolerance from the `tol` query parameter,
+// raised to what `bits` bits of precision can resolve. Reports errors
+// to the user; the caller should return if `ok` is false.
+func parseTol(w h.ResponseWriter, r *h.Request, bits uint) (tol *big.Float, ok bool) {
+	tol, err := parseFloatParam(r, "tol", defaultTol, bits)
+	if err != nil || tol.Sign() <= 0 {
+		h.Error(w, "Error: tol must be a positive number", h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	if min := new(big.Float).SetMantExp(newFloat(bits, 1), -int(bits-8)); tol.Cmp(min) < 0 {
+		tol = min
+	}
+
+	return tol, true
+}
+
+// Returns the largest sum of the absolute weights of the edges into a
+// node of the adjacency matrix `m`.
+func maxInDegree(m [][]*big.Int) *big.Int {
+	deg := new(big.Int)
+	for j := range m {
+		sum := new(big.Int)
+		for i := range m {
+			sum.Add(sum, new(big.Int).Abs(m[i][j]))
+		}
+
+		if sum.Cmp(deg) > 0 {
+			deg = sum
+		}
+	}
+
+	return deg
+}
+
+// Rejects matrices with negative entries. Reports errors to the user;
+// the caller should return if the result is false.
+func checkNonNegative(w h.ResponseWriter, m [][]*big.Int) bool {
+	for _, row := range m {
+		for _, d := range row {
+			if d.Sign() < 0 {
+				h.Error(w, "Error: edge weights must be non-negative", h.StatusBadRequest)
+
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns aᵀ·x.
+func mulTransposed(a [][]*big.Float, x []*big.Float, bits uint) []*big.Float {
+	out := make([]*big.Float, len(x))
+	for j := range out {
+		out[j] = newFloat(bits, 0)
+	}
+
+	t := new(big.Float)
+	for i, row := range a {
+		for j, aij := range row {
+			if aij.Sign() != 0 {
+				out[j].Add(out[j], t.Mul(aij, x[i]))
+			}
+		}
+	}
+
+	return out
+}
+
+// Repeatedly replaces `x` with step(x) until the total change of its
+// entries is less than `tol`. Returns the number of iterations, or
+// reports an error to the user if they don't converge or overflow; the
+// caller should return if `ok` is false.
+func powerIterate(
+	w h.ResponseWriter,
+	x []*big.Float,
+	tol *big.Float,
+	bits uint,
+	step func(x []*big.Float) []*big.Float,
+) (iterations int, ok bool) {
+	d := new(big.Float)
+
+	for iterations < maxCentralityIterations {
+		next := step(x)
+		iterations++
+
+		change := newFloat(bits, 0)
+		for i := range x {
+			d.Sub(next[i], x[i])
+			change.Add(change, d.Abs(d))
+		}
+
+		if change.IsInf() {
+			h.Error(w, "Error: the iterations overflow", h.StatusBadRequest)
+
+			return 0, false
+		}
+
+		copy(x, next)
+		if change.Cmp(tol) < 0 {
+			return iterations, true
+		}
+	}
+
+	h.Error(w, fmt.Sprintf("Error: no convergence after %d iterations", maxCentralityIterations),
+		h.StatusBadRequest)
+
+	return 0, false
+}
+
+// Writes the centrality response for the scores `x`.
+func writeCentrality(w h.ResponseWriter, labels []string, x []*big.Float, iterations, prec int) {
+	res := centrality{Iterations: iterations, Scores: []nodeScore{}}
+	for i, s := range x {
+		res.Scores = append(res.Scores, nodeScore{labels[i], ftos(s, prec)})
+	}
+
+	writeJSON(w, res)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandlePageRank(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"cycle",
+			"prec=5",
+			[]byte("0,1,0\n0,0,1\n1,0,0"),
+			`{"iterations":1,"scores":[{"node":"1","score":"0.33333"},{"node":"2","score":"0.33333"},` +
+				`{"node":"3","score":"0.33333"}]}` + "\n",
+			200,
+		},
+		{
+			"dangling-node",
+			"prec=10&header=true",
+			[]byte("a,b\n0,1\n0,0"),
+			`{"iterations":33,"scores":[{"node":"a","score":"0.350877193"},{"node":"b","score":"0.649122807"}]}` + "\n",
+			200,
+		},
+		{
+			"weighted-damping",
+			"prec=6&damping=0.5&tol=1e-9",
+			[]byte("0,3,1\n1,0,0\n1,0,0"),
+			`{"iterations":28,"scores":[{"node":"1","score":"0.444444"},{"node":"2","score":"0.333333"},` +
+				`{"node":"3","score":"0.222222"}]}` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			`{"iterations":0,"scores":[]}` + "\n",
+			200,
+		},
+		{
+			"negative-weight",
+			"",
+			[]byte("0,-1\n1,0"),
+			"Error: edge weights must be non-negative\n",
+			400,
+		},
+		{
+			"too-many-nodes",
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", 100)+"1\n", 101)),
+			"Error: the graph is limited to 100 nodes\n",
+			400,
+		},
+		{
+			"prec-too-high",
+			"prec=31",
+			[]byte("0,1\n1,0"),
+			"Error: prec must be an integer between 1 and 30\n",
+			400,
+		},
+		{
+			"invalid-damping",
+			"damping=1.5",
+			[]byte("0,1\n1,0"),
+			"Error: damping must be a number between 0 and 1\n",
+			400,
+		},
+		{
+			"invalid-tol",
+			"tol=0",
+			[]byte("0,1\n1,0"),
+			"Error: tol must be a positive number\n",
+			400,
+		},
+		{
+			"infinite-tol",
+			"tol=Inf",
+			[]byte("0,1\n1,0"),
+			"Error: tol must be a positive number\n",
+			400,
+		},
+		{
+			"not-square",
+			"",
+			[]byte("a,b\n0,1\n1,0"),
+			"Error: matrix is not square\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handlePageRank)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleCentrality(t *testing.T) {
+	// A star with the edges pointing into node 1, plus 2 -> 3.
+	star := []byte("0,0,0\n1,0,1\n1,0,0")
+
+	tests := []queryTestCase{
+		{
+			"degree",
+			"type=degree",
+			star,
+			`{"iterations":0,"scores":[{"node":"1","score":"1"},{"node":"2","score":"0"},{"node":"3","score":"0.5"}]}` + "\n",
+			200,
+		},
+		{
+			"eigenvector",
+			"type=eigenvector&prec=8",
+			[]byte("0,1,1\n1,0,1\n1,1,0"),
+			`{"iterations":2,"scores":[{"node":"1","score":"0.57735027"},{"node":"2","score":"0.57735027"},` +
+				`{"node":"3","score":"0.57735027"}]}` + "\n",
+			200,
+		},
+		{
+			"eigenvector-bipartite",
+			"type=eigenvector&prec=8&header=true",
+			[]byte("x,y\n0,2\n2,0"),
+			`{"iterations":2,"scores":[{"node":"x","score":"0.70710678"},{"node":"y","score":"0.70710678"}]}` + "\n",
+			200,
+		},
+		{
+			"katz",
+			"type=katz&alpha=0.25&prec=8",
+			star,
+			`{"iterations":4,"scores":[{"node":"1","score":"1.5625"},{"node":"2","score":"1"},{"node":"3","score":"1.25"}]}` + "\n",
+			200,
+		},
+		{
+			"katz-divergent",
+			"type=katz&alpha=0.5",
+			star,
+			"Error: alpha must be less than 1/2\n",
+			400,
+		},
+		{
+			"katz-huge-alpha",
+			"type=katz&alpha=1e400000000",
+			[]byte("0,1\n1,0"),
+			"Error: alpha must be less than 1/1\n",
+			400,
+		},
+		{
+			"katz-too-many-nodes",
+			"type=katz&alpha=0.001",
+			[]byte(strings.Repeat(strings.Repeat("0,", 100)+"0\n", 101)),
+			"Error: the graph is limited to 100 nodes\n",
+			400,
+		},
+		{
+			"eigenvector-prec-too-high",
+			"type=eigenvector&prec=31",
+			star,
+			"Error: prec must be an integer between 1 and 30\n",
+			400,
+		},
+		{
+			"unknown-type",
+			"type=closeness",
+			star,
+			"Error: type must be one of degree, eigenvector or katz\n",
+			400,
+		},
+		{
+			"invalid-alpha",
+			"type=katz&alpha=x",
+			star,
+			"Error: alpha must be a positive number\n",
+			400,
+		},
+		{
+			"infinite-alpha",
+			"type=katz&alpha=Inf",
+			star,
+			"Error: alpha must be a positive number\n",
+			400,
+		},
+		{
+			"infinite-tol",
+			"type=eigenvector&tol=%2BInf",
+			star,
+			"Error: tol must be a positive number\n",
+			400,
+		},
+		{
+			"eigenvector-negative-weight",
+			"type=eigenvector",
+			[]byte("0,-1\n1,0"),
+			"Error: edge weights must be non-negative\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleCentrality)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
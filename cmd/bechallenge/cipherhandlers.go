@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"unicode/utf8"
+)
+
+// Caps the size of a cipher alphabet.
+const maxAlphabetSize = 1 << 16
+
+// Handles Hill cipher requests by validating the supplied key matrix
+// of int literals and returning the `text` form field encrypted (the
+// default) or decrypted with it, as selected by the `mode` query
+// parameter.
+//
+// The `alphabet` query parameter lists the characters of the alphabet
+// in order, with ranges like A-Z (the default) or a-z0-9. The
+// characters of the text that aren't in it are left as they are. The
+// others are taken in blocks of n, the size of the key, and each block
+// is replaced with the product of the key (or its inverse modulo the
+// size of the alphabet when decrypting) and the block as a column
+// vector. When encrypting, the last block is padded with the
+// character in the `pad` query parameter (the last one of the alphabet
+// by default). The key must be invertible modulo the size of the
+// alphabet, and inverting it limits its size to maxCofactorSize.
+// Expects the matrix CSV in the request context.
+func handleHill(w h.ResponseWriter, r *h.Request) {
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = "encrypt"
+	}
+	if mode != "encrypt" && mode != "decrypt" {
+		h.Error(w, "Error: mode must be one of encrypt or decrypt", h.StatusBadRequest)
+
+		return
+	}
+
+	s := r.FormValue("alphabet")
+	if s == "" {
+		s = "A-Z"
+	}
+	alphabet, err := parseAlphabet(s)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	index := make(map[rune]int64, len(alphabet))
+	for i, c := range alphabet {
+		index[c] = int64(i)
+	}
+
+	pad := alphabet[len(alphabet)-1]
+	if s := r.FormValue("pad"); s != "" {
+		c, size := utf8.DecodeRuneInString(s)
+		if _, found := index[c]; !found || size != len(s) {
+			h.Error(w, "Error: pad must be a character of the alphabet", h.StatusBadRequest)
+
+			return
+		}
+		pad = c
+	}
+
+	key, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(key) == 0 {
+		h.Error(w, "Error: key is empty", h.StatusBadRequest)
+
+		return
+	}
+	if len(key) > maxCofactorSize {
+		h.Error(w, fmt.Sprintf("Error: key is limited to %dx%d", maxCofactorSize, maxCofactorSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	mod := big.NewInt(int64(len(alphabet)))
+	inv, ok := inverseMod(key, mod)
+	if !ok {
+		h.Error(w, fmt.Sprintf("Error: key is not invertible modulo %d", len(alphabet)),
+			h.StatusBadRequest)
+
+		return
+	}
+	if mode == "decrypt" {
+		key = inv
+	}
+
+	text := []rune(r.FormValue("text"))
+
+	// The positions of the characters to transform.
+	var pos []int
+	for i, c := range text {
+		if _, found := index[c]; found {
+			pos = append(pos, i)
+		}
+	}
+
+	n := len(key)
+	if rem := len(pos) % n; rem != 0 {
+		if mode == "decrypt" {
+			h.Error(w, fmt.Sprintf("Error: the text must have a multiple of %d characters of the alphabet", n),
+				h.StatusBadRequest)
+
+			return
+		}
+
+		for range n - rem {
+			pos = append(pos, len(text))
+			text = append(text, pad)
+		}
+	}
+
+	block, out, t := make([]*big.Int, n), new(big.Int), new(big.Int)
+	for b := 0; b < len(pos); b += n {
+		for k := range block {
+			block[k] = big.NewInt(index[text[pos[b+k]]])
+		}
+
+		for i, row := range key {
+			out.SetInt64(0)
+			for j, x := range row {
+				out.Add(out, t.Mul(x, block[j]))
+			}
+
+			text[pos[b+i]] = alphabet[out.Mod(out, mod).Int64()]
+		}
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprintln(w, string(text))
+}
+
+// Parses the alphabet `s`: a list of characters and ranges of them,
+// like a-z0-9. A dash at either end stands for itself.
+func parseAlphabet(s string) ([]rune, error) {
+	var alphabet []rune
+	seen := make(map[rune]bool)
+
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		lo, hi := rs[i], rs[i]
+		if i+2 < len(rs) && rs[i+1] == '-' {
+			hi = rs[i+2]
+			i += 2
+		}
+		if hi < lo {
+			return nil, fmt.Errorf("alphabet: invalid range %q", string([]rune{lo, '-', hi}))
+		}
+
+		if len(alphabet)+int(hi-lo) >= maxAlphabetSize {
+			return nil, fmt.Errorf("alphabet must have at most %d characters", maxAlphabetSize)
+		}
+
+		for c := lo; c <= hi; c++ {
+			// Ranges across the UTF-16 surrogates would include code
+			// points that can't be encoded.
+			if !utf8.ValidRune(c) {
+				return nil, fmt.Errorf("alphabet: invalid character %U", c)
+			}
+			if seen[c] {
+				return nil, fmt.Errorf("alphabet: duplicate character %q", c)
+			}
+
+			seen[c] = true
+			alphabet = append(alphabet, c)
+		}
+	}
+
+	if len(alphabet) < 2 {
+		return nil, errors.New("alphabet must have at least 2 characters")
+	}
+
+	return alphabet, nil
+}
+
+// Returns the inverse of the square integer matrix `m` modulo `mod`,
+// with the entries in [0, mod), or false if it isn't invertible, i.e.
+// its determinant isn't coprime with `mod`.
+func inverseMod(m [][]*big.Int, mod *big.Int) ([][]*big.Int, bool) {
+	det := detInt(m)
+	if det.Sign() == 0 {
+		return nil, false
+	}
+
+	detInv := new(big.Int).ModInverse(new(big.Int).Mod(det, mod), mod)
+	if detInv == nil {
+		return nil, false
+	}
+
+	// m⁻¹ = det⁻¹·adj(m)
+	inv := nonsingularAdjugate(m, det)
+	for _, row := range inv {
+		for _, x := range row {
+			x.Mul(x, detInv).Mod(x, mod)
+		}
+	}
+
+	return inv, true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHandleHill(t *testing.T) {
+	key := []byte("3,3\n2,5")
+
+	tests := []queryTestCase{
+		{
+			"encrypt",
+			"text=HELP",
+			key,
+			"HIAT\n",
+			200,
+		},
+		{
+			"decrypt",
+			"mode=decrypt&text=HIAT",
+			key,
+			"HELP\n",
+			200,
+		},
+		{
+			"encrypt-3x3",
+			"mode=encrypt&text=ACT",
+			[]byte("6,24,1\n13,16,10\n20,17,15"),
+			"POH\n",
+			200,
+		},
+		{
+			"decrypt-3x3",
+			"mode=decrypt&text=POH",
+			[]byte("6,24,1\n13,16,10\n20,17,15"),
+			"ACT\n",
+			200,
+		},
+		{
+			"passthrough-and-padding",
+			"text=HEL+P!",
+			key,
+			"HIA T!\n",
+			200,
+		},
+		{
+			"custom-pad",
+			"text=HEL&pad=X",
+			key,
+			"HIYH\n",
+			200,
+		},
+		{
+			"custom-alphabet",
+			"alphabet=a-z0-9&text=help+me+2",
+			[]byte("5,8\n17,3"),
+			"5x5q ua yf\n",
+			200,
+		},
+		{
+			"custom-alphabet-decrypt",
+			"alphabet=a-z0-9&mode=decrypt&text=5x5q+ua+yf",
+			[]byte("5,8\n17,3"),
+			"help me 29\n",
+			200,
+		},
+		{
+			"negative-key-entries",
+			"text=HELP",
+			[]byte("-23,3\n2,-21"),
+			"HIAT\n",
+			200,
+		},
+		{
+			"not-invertible",
+			"text=HELP",
+			[]byte("2,4\n6,8"),
+			"Error: key is not invertible modulo 26\n",
+			400,
+		},
+		{
+			"decrypt-partial-block",
+			"mode=decrypt&text=HIA",
+			key,
+			"Error: the text must have a multiple of 2 characters of the alphabet\n",
+			400,
+		},
+		{
+			"unknown-mode",
+			"mode=crack&text=HELP",
+			key,
+			"Error: mode must be one of encrypt or decrypt\n",
+			400,
+		},
+		{
+			"invalid-range",
+			"alphabet=Z-A&text=HELP",
+			key,
+			"Error: alphabet: invalid range \"Z-A\"\n",
+			400,
+		},
+		{
+			"duplicate-character",
+			"alphabet=A-ZA&text=HELP",
+			key,
+			"Error: alphabet: duplicate character 'A'\n",
+			400,
+		},
+		{
+			"surrogates",
+			"alphabet=%ED%9F%BF-%EE%80%80&text=HELP",
+			key,
+			"Error: alphabet: invalid character U+D800\n",
+			400,
+		},
+		{
+			"invalid-pad",
+			"text=HEL&pad=xy",
+			key,
+			"Error: pad must be a character of the alphabet\n",
+			400,
+		},
+		{
+			"key-too-large",
+			"text=HELLO",
+			onesCSV(maxCofactorSize + 1),
+			"Error: key is limited to 48x48\n",
+			400,
+		},
+		{
+			"empty-key",
+			"text=HELP",
+			[]byte{},
+			"Error: key is empty\n",
+			400,
+		},
+		{
+			"not-square",
+			"text=HELP",
+			[]byte("3,3"),
+			"Error: matrix is not square\n",
+			400,
+		},
+	}
+
+	h := webApiMiddleware(handleHill)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	h "net/http"
+	"strconv"
+)
+
+const (
+	// Ryser's formula takes O(2ⁿ·n) steps.
+	maxPermanentSize = 20
+	// Computing the cofactors takes O(n³) steps on fractions, and n²
+	// determinants for a singular matrix.
+	maxCofactorSize         = 48
+	maxSingularCofactorSize = 24
+)
+
+// Handles permanent requests by validating the supplied matrix of int
+// literals and returning a string with its permanent. Uses Ryser's
+// formula, so the matrix can be at most maxPermanentSize x
+// maxPermanentSize. Expects the matrix CSV in the request context.
+func handlePermanent(w h.ResponseWriter, r *h.Request) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	if len(m) > maxPermanentSize {
+		h.Error(
+			w,
+			fmt.Sprintf("Error: permanent is limited to %dx%d matrices",
+				maxPermanentSize, maxPermanentSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, permanent(m), "\n")
+}
+
+// Handles minor requests by validating the supplied matrix of int
+// literals and returning a string with the determinant of the
+// submatrix without the row and column given by the `row` and `col`
+// query parameters (1-based). The determinant takes O(n³) steps, so the
+// matrix is limited to maxCofactorSize x maxCofactorSize. Expects the
+// matrix CSV in the request context.
+func handleMinor(w h.ResponseWriter, r *h.Request) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	if len(m) > maxCofactorSize {
+		h.Error(
+			w,
+			fmt.Sprintf("Error: minors are limited to %dx%d", maxCofactorSize, maxCofactorSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	i, err1 := strconv.Atoi(r.FormValue("row"))
+	j, err2 := strconv.Atoi(r.FormValue("col"))
+	if err1 != nil || err2 != nil || i < 1 || i > len(m) || j < 1 || j > len(m) {
+		h.Error(w, "Error: row and col must be between 1 and the size of the matrix",
+			h.StatusBadRequest)
+
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, detInt(submatrix(m, i-1, j-1)), "\n")
+}
+
+// Handles cofactors requests by validating the supplied matrix of int
+// literals and returning its cofactor matrix. Expects the matrix CSV in
+// the request context.
+func handleCofactors(w h.ResponseWriter, r *h.Request) {
+	adj, ok := adjugate(w, r)
+	if !ok {
+		return
+	}
+
+	// The cofactor matrix is the transpose of the adjugate.
+	fmt.Fprint(w, mtos(transpose(adj)))
+}
+
+// Handles adjugate requests by validating the supplied matrix of int
+// literals and returning its adjugate (the transpose of its cofactor
+// matrix). Expects the matrix CSV in the request context.
+func handleAdjugate(w h.ResponseWriter, r *h.Request) {
+	adj, ok := adjugate(w, r)
+	if !ok {
+		return
+	}
+
+	fmt.Fprint(w, mtos(adj))
+}
+
+// Parses the matrix in the request context and computes its adjugate.
+// Reports errors to the user; the caller should return if `ok` is
+// false.
+//
+// For a non-singular matrix the adjugate is det(m)·m⁻¹, which we get
+// in O(n³) steps, so its size is limited to maxCofactorSize. A singular
+// one takes a determinant per entry, so its size is limited to
+// maxSingularCofactorSize.
+func adjugate(w h.ResponseWriter, r *h.Request) (adj [][]*big.Int, ok bool) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if len(m) > maxCofactorSize {
+		h.Error(
+			w,
+			fmt.Sprintf("Error: cofactors are limited to %dx%d", maxCofactorSize, maxCofactorSize),
+			h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	det := detInt(m)
+
+	if det.Sign() != 0 {
+		return nonsingularAdjugate(m, det), true
+	}
+
+	if len(m) > maxSingularCofactorSize {
+		h.Error(
+			w,
+			fmt.Sprintf("Error: cofactors of singular matrices are limited to %dx%d",
+				maxSingularCofactorSize, maxSingularCofactorSize),
+			h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	adj = make([][]*big.Int, len(m))
+	for i := range m {
+		adj[i] = make([]*big.Int, len(m))
+		for j := range m {
+			// adj[i][j] is the (j, i) cofactor.
+			c := detInt(submatrix(m, j, i))
+			if (i+j)%2 == 1 {
+				c.Neg(c)
+			}
+			adj[i][j] = c
+		}
+	}
+
+	return adj, true
+}
+
+// Returns the adjugate det·m⁻¹ of the non-singular matrix `m` with
+// the determinant `det`.
+func nonsingularAdjugate(m [][]*big.Int, det *big.Int) [][]*big.Int {
+	inv, err := inverseRat(ratMatrix(m))
+	if err != nil {
+		// Can't happen as the determinant is non-zero.
+		panic(err)
+	}
+
+	adj := make([][]*big.Int, len(m))
+	d := new(big.Rat).SetInt(det)
+	for i, row := range inv {
+		adj[i] = make([]*big.Int, len(row))
+		for j, x := range row {
+			// The entries of det(m)·m⁻¹ are integers.
+			adj[i][j] = new(big.Int).Set(x.Mul(x, d).Num())
+		}
+	}
+
+	return adj
+}
+
+// Returns the permanent of the square matrix `m` computed with Ryser's
+// formula, visiting the column subsets in Gray code order so that each
+// step only adds or removes one column from the row sums.
+func permanent(m [][]*big.Int) *big.Int {
+	n := len(m)
+	perm := new(big.Int)
+	if n == 0 {
+		return perm.SetInt64(1)
+	}
+
+	// Row sums over the current column subset.
+	sums := make([]*big.Int, n)
+	for i := range sums {
+		sums[i] = new(big.Int)
+	}
+
+	prod := new(big.Int)
+	var gray uint64
+	for k := uint64(1); k < 1<<n; k++ {
+		// The column that flips between the previous and this subset.
+		next := k ^ (k >> 1)
+		j := bits.TrailingZeros64(gray ^ next)
+		added := next&(1<<j) != 0
+		gray = next
+
+		for i := range sums {
+			if added {
+				sums[i].Add(sums[i], m[i][j])
+			} else {
+				sums[i].Sub(sums[i], m[i][j])
+			}
+		}
+
+		prod.SetInt64(1)
+		for _, s := range sums {
+			prod.Mul(prod, s)
+		}
+
+		// (-1)^(n - |subset|)
+		if (n-bits.OnesCount64(gray))%2 == 1 {
+			perm.Sub(perm, prod)
+		} else {
+			perm.Add(perm, prod)
+		}
+	}
+
+	return perm
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Returns the CSV of the n x n matrix of ones.
+func onesCSV(n int) []byte {
+	return []byte(strings.Repeat(strings.Repeat("1,", n-1)+"1\n", n))
+}
+
+func TestCofactorHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"permanent",
+			handlePermanent,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"450\n",
+			200,
+		},
+		{
+			"permanent-perfect-matchings",
+			handlePermanent,
+			"",
+			[]byte("0,1,1,1\n1,0,1,1\n1,1,0,1\n1,1,1,0"),
+			"9\n",
+			200,
+		},
+		{
+			"permanent-large-integers",
+			handlePermanent,
+			"",
+			[]byte("12345678901234567890,1\n1,12345678901234567890"),
+			"152415787532388367501905199875019052101\n",
+			200,
+		},
+		{
+			"permanent-empty-csv",
+			handlePermanent,
+			"",
+			[]byte{},
+			"1\n",
+			200,
+		},
+		{
+			"minor",
+			handleMinor,
+			"row=2&col=3",
+			[]byte("1,2,3\n4,5,6\n7,8,10"),
+			"-6\n",
+			200,
+		},
+		{
+			"minor-out-of-range",
+			handleMinor,
+			"row=4&col=1",
+			[]byte("1,2,3\n4,5,6\n7,8,10"),
+			"Error: row and col must be between 1 and the size of the matrix\n",
+			400,
+		},
+		{
+			"cofactors",
+			handleCofactors,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,10"),
+			"2,2,-3\n4,-11,6\n-3,6,-3\n",
+			200,
+		},
+		{
+			"adjugate",
+			handleAdjugate,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,10"),
+			"2,4,-3\n2,-11,6\n-3,6,-3\n",
+			200,
+		},
+		{
+			"adjugate-singular",
+			handleAdjugate,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"-3,6,-3\n6,-12,6\n-3,6,-3\n",
+			200,
+		},
+		{
+			"adjugate-1x1",
+			handleAdjugate,
+			"",
+			[]byte("0"),
+			"1\n",
+			200,
+		},
+		{
+			"permanent-too-large",
+			handlePermanent,
+			"",
+			onesCSV(maxPermanentSize + 1),
+			"Error: permanent is limited to 20x20 matrices\n",
+			400,
+		},
+		{
+			"minor-too-large",
+			handleMinor,
+			"row=1&col=1",
+			onesCSV(maxCofactorSize + 1),
+			"Error: minors are limited to 48x48\n",
+			400,
+		},
+		{
+			"cofactors-too-large",
+			handleCofactors,
+			"",
+			onesCSV(maxCofactorSize + 1),
+			"Error: cofactors are limited to 48x48\n",
+			400,
+		},
+		{
+			"adjugate-singular-too-large",
+			handleAdjugate,
+			"",
+			onesCSV(maxSingularCofactorSize + 1),
+			"Error: cofactors of singular matrices are limited to 24x24\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			handleAdjugate,
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := webApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
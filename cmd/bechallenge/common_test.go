@@ -15,6 +15,25 @@ type formFileTestCase struct {
 	wantStatus int
 }
 
+// Like formFileTestCase, for handlers that take query parameters.
+type queryTestCase struct {
+	name       string
+	query      string
+	payload    []byte
+	wantBody   string
+	wantStatus int
+}
+
+// Like queryTestCase, for handlers that take two form files.
+type twoFileTestCase struct {
+	name       string
+	query      string
+	payload    []byte
+	payload2   []byte
+	wantBody   string
+	wantStatus int
+}
+
 // Helper; builds the test request for a form file upload, feeds it to
 // the provided handler and asserts the response.
 func runFormFileTestCase(
@@ -26,22 +45,55 @@ func runFormFileTestCase(
 ) {
 	t.Helper()
 
+	runQueryTestCase(t, handler, "", payload, wantBody, wantStatus)
+}
+
+// Helper; like runFormFileTestCase, but adds the query string `query`
+// to the request URL.
+func runQueryTestCase(
+	t *testing.T,
+	handler http.HandlerFunc,
+	query string,
+	payload []byte,
+	wantBody string,
+	wantStatus int,
+) {
+	t.Helper()
+
+	runFormFilesTestCase(t, handler, query, map[string][]byte{"file": payload},
+		wantBody, wantStatus)
+}
+
+// Helper; like runQueryTestCase, but uploads each of `files` as a form
+// file named after its key.
+func runFormFilesTestCase(
+	t *testing.T,
+	handler http.HandlerFunc,
+	query string,
+	files map[string][]byte,
+	wantBody string,
+	wantStatus int,
+) {
+	t.Helper()
+
 	buf := new(bytes.Buffer)
 	writer := multipart.NewWriter(buf)
-	fWriter, err := writer.CreateFormFile("file", "file")
-	if err != nil {
-		t.Fatalf("unexpected multipart error %v", err)
-	}
+	for name, payload := range files {
+		fWriter, err := writer.CreateFormFile(name, name)
+		if err != nil {
+			t.Fatalf("unexpected multipart error %v", err)
+		}
 
-	if _, err = fWriter.Write(payload); err != nil {
-		t.Fatalf("unexpected write error %v", err)
+		if _, err = fWriter.Write(payload); err != nil {
+			t.Fatalf("unexpected write error %v", err)
+		}
 	}
 
 	if err := writer.Close(); err != nil {
 		t.Fatalf("unexpected writer close error %v", err)
 	}
 
-	r := httptest.NewRequest("POST", "/", buf)
+	r := httptest.NewRequest("POST", "/?"+query, buf)
 	r.Header.Set("Content-Type", writer.FormDataContentType())
 
 	w := httptest.NewRecorder()
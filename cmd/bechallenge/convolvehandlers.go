@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+	"strconv"
+)
+
+const (
+	// Caps the rows and columns of an uploaded kernel.
+	maxKernelSize = 31
+
+	// Caps the work of a convolve request, the number of output
+	// elements times the number of kernel weights.
+	maxConvolveWork = 1 << 21
+)
+
+// A convolution kernel preset: integer weights and a common divisor.
+type kernelPreset struct {
+	weights [][]int64
+	divisor int64
+}
+
+// Kernel presets supported by the convolve API.
+var kernelPresets = map[string]kernelPreset{
+	"box": {
+		[][]int64{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}},
+		9,
+	},
+	"gaussian3": {
+		[][]int64{{1, 2, 1}, {2, 4, 2}, {1, 2, 1}},
+		16,
+	},
+	"sobel-x": {
+		[][]int64{{-1, 0, 1}, {-2, 0, 2}, {-1, 0, 1}},
+		1,
+	},
+	"laplacian": {
+		[][]int64{{0, 1, 0}, {1, -4, 1}, {0, 1, 0}},
+		1,
+	},
+}
+
+// Handles convolve requests by validating the supplied matrix of int
+// literals and returning its 2D convolution with a kernel. The kernel
+// is either one of the presets (box, gaussian3, sobel-x, laplacian)
+// named by the `kernel` query parameter, or an integer matrix uploaded
+// as the "file2" form file, but not both. Expects the matrix CSV in the
+// request context.
+//
+// The kernel is centered on each element (on the lower-right of the
+// two middle elements for even sizes) and flipped, as in the
+// mathematical definition of convolution. The `padding` query
+// parameter selects how elements outside the matrix are treated: zero
+// (default), reflect (mirrored about the edge elements) or wrap. With
+// `stride=s` only every s-th row and column of the output is kept.
+// Results are exact integers or fractions. The number of output
+// elements times the size of the kernel is limited to maxConvolveWork.
+func handleConvolve(w h.ResponseWriter, r *h.Request) {
+	padding := r.FormValue("padding")
+	if padding == "" {
+		padding = "zero"
+	}
+	if padding != "zero" && padding != "reflect" && padding != "wrap" {
+		h.Error(w, "Error: padding must be one of zero, reflect or wrap", h.StatusBadRequest)
+
+		return
+	}
+
+	stride := 1
+	if s := r.FormValue("stride"); s != "" {
+		var err error
+		if stride, err = strconv.Atoi(s); err != nil || stride < 1 {
+			h.Error(w, "Error: stride must be a positive integer", h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	kernel, ok := convolutionKernel(w, r)
+	if !ok {
+		return
+	}
+	outRows, outCols := (len(m)+stride-1)/stride, (ncols(m)+stride-1)/stride
+	if outRows*outCols*len(kernel)*ncols(kernel) > maxConvolveWork {
+		h.Error(w, "Error: the matrix is too large for the kernel", h.StatusBadRequest)
+
+		return
+	}
+
+	fmt.Fprint(w, rmtos(convolve(ratMatrix(m), kernel, padding, stride), 0))
+}
+
+// Gets the kernel for a convolve request. Reports errors to the user;
+// the caller should return if `ok` is false.
+func convolutionKernel(w h.ResponseWriter, r *h.Request) (kernel [][]*big.Rat, ok bool) {
+	if name := r.FormValue("kernel"); name != "" {
+		if r.MultipartForm != nil && len(r.MultipartForm.File["file2"]) > 0 {
+			h.Error(w, "Error: kernel and file2 are mutually exclusive", h.StatusBadRequest)
+
+			return nil, false
+		}
+
+		p, found := kernelPresets[name]
+		if !found {
+			h.Error(w, fmt.Sprintf("Error: unknown kernel %q", name), h.StatusBadRequest)
+
+			return nil, false
+		}
+
+		kernel = make([][]*big.Rat, len(p.weights))
+		for i, row := range p.weights {
+			kernel[i] = make([]*big.Rat, len(row))
+			for j, x := range row {
+				kernel[i][j] = big.NewRat(x, p.divisor)
+			}
+		}
+
+		return kernel, true
+	}
+
+	recs, ok := formRecords(w, r, "file2")
+	if !ok {
+		return nil, false
+	}
+
+	k, err := atom(recs)
+	if err != nil {
+		h.Error(w, "Error: parsing CSV (file2): "+err.Error(), h.StatusBadRequest)
+
+		return nil, false
+	}
+	if len(k) == 0 {
+		h.Error(w, "Error: kernel is empty", h.StatusBadRequest)
+
+		return nil, false
+	}
+	if len(k) > maxKernelSize || ncols(k) > maxKernelSize {
+		h.Error(w, fmt.Sprintf("Error: kernel is limited to %dx%d", maxKernelSize, maxKernelSize),
+			h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	return ratMatrix(k), true
+}
+
+// Returns the convolution of the matrix `m` with `kernel` (see
+// handleConvolve).
+func convolve(m, kernel [][]*big.Rat, padding string, stride int) [][]*big.Rat {
+	rows, cols := len(m), ncols(m)
+	kRows, kCols := len(kernel), ncols(kernel)
+	cr, cc := kRows/2, kCols/2
+
+	var out [][]*big.Rat
+	t := new(big.Rat)
+
+	for i := 0; i < rows; i += stride {
+		var row []*big.Rat
+
+		for j := 0; j < cols; j += stride {
+			acc := new(big.Rat)
+
+			for ki := range kernel {
+				for kj, k := range kernel[ki] {
+					// The kernel is flipped: its (ki, kj) weight
+					// applies to the element at the mirrored offset.
+					si, ok1 := padIndex(i+cr-ki, rows, padding)
+					sj, ok2 := padIndex(j+cc-kj, cols, padding)
+					if ok1 && ok2 {
+						acc.Add(acc, t.Mul(k, m[si][sj]))
+					}
+				}
+			}
+
+			row = append(row, acc)
+		}
+
+		out = append(out, row)
+	}
+
+	return out
+}
+
+// Maps the possibly out of range index `i` into [0, n) according to
+// `padding`. Returns false if the element is a zero padding one.
+func padIndex(i, n int, padding string) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+
+	switch padding {
+	case "wrap":
+		return ((i % n) + n) % n, true
+	case "reflect":
+		if n == 1 {
+			return 0, true
+		}
+
+		// Mirror about the edge elements: the sequence has a period of
+		// 2(n-1).
+		p := 2 * (n - 1)
+		i = ((i % p) + p) % p
+		if i >= n {
+			i = p - i
+		}
+
+		return i, true
+	}
+
+	return 0, false
+}
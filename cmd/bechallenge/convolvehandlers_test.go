@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleConvolve(t *testing.T) {
+	tests := []twoFileTestCase{
+		{
+			"box-zero-padding",
+			"kernel=box",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			nil,
+			"4/3,7/3,16/9\n3,5,11/3\n8/3,13/3,28/9\n",
+			200,
+		},
+		{
+			"box-wrap-padding",
+			"kernel=box&padding=wrap",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			nil,
+			"5,5,5\n5,5,5\n5,5,5\n",
+			200,
+		},
+		{
+			"laplacian-reflect-padding",
+			"kernel=laplacian&padding=reflect",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			nil,
+			"8,6,4\n2,0,-2\n-4,-6,-8\n",
+			200,
+		},
+		{
+			"sobel-x-is-flipped",
+			"kernel=sobel-x&padding=reflect",
+			[]byte("1,2,3\n1,2,3\n1,2,3"),
+			nil,
+			"0,-8,0\n0,-8,0\n0,-8,0\n",
+			200,
+		},
+		{
+			"gaussian3-stride",
+			"kernel=gaussian3&stride=2",
+			[]byte("16,0,0\n0,0,0\n0,0,16"),
+			nil,
+			"4,0\n0,4\n",
+			200,
+		},
+		{
+			"kernel-file",
+			"",
+			[]byte("1,2\n3,4"),
+			[]byte("1,0"),
+			"2,0\n4,0\n",
+			200,
+		},
+		{
+			"preset-and-file",
+			"kernel=box",
+			[]byte("1,2\n3,4"),
+			[]byte("1,0"),
+			"Error: kernel and file2 are mutually exclusive\n",
+			400,
+		},
+		{
+			"kernel-file-large-integers",
+			"",
+			[]byte("12345678901234567890"),
+			[]byte("-2"),
+			"-24691357802469135780\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"kernel=box",
+			[]byte{},
+			nil,
+			"\n",
+			200,
+		},
+		{
+			"missing-kernel",
+			"",
+			[]byte("1"),
+			nil,
+			"Error: form file \"file2\" expected\n",
+			400,
+		},
+		{
+			"kernel-too-large",
+			"",
+			[]byte("1"),
+			[]byte(strings.Repeat("1,", 31) + "1"),
+			"Error: kernel is limited to 31x31\n",
+			400,
+		},
+		{
+			"matrix-too-large-for-kernel",
+			"",
+			[]byte(strings.Repeat("1,", 2182) + "1"),
+			[]byte(strings.Repeat(strings.Repeat("1,", 30)+"1\n", 31)),
+			"Error: the matrix is too large for the kernel\n",
+			400,
+		},
+		{
+			"unknown-kernel",
+			"kernel=sharpen",
+			[]byte("1"),
+			nil,
+			"Error: unknown kernel \"sharpen\"\n",
+			400,
+		},
+		{
+			"invalid-padding",
+			"kernel=box&padding=edge",
+			[]byte("1"),
+			nil,
+			"Error: padding must be one of zero, reflect or wrap\n",
+			400,
+		},
+		{
+			"invalid-stride",
+			"kernel=box&stride=0",
+			[]byte("1"),
+			nil,
+			"Error: stride must be a positive integer\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleConvolve)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{"file": tt.payload}
+			if tt.payload2 != nil {
+				files["file2"] = tt.payload2
+			}
+
+			runFormFilesTestCase(t, h, tt.query, files, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
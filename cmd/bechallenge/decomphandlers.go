@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+// Caps the rows and columns of pinv requests; the exact rank
+// factorization takes O(m·n·r) operations on fractions that grow with
+// the size of the matrix.
+const maxPinvSize = 24
+
+// A compact singular value decomposition A = U·Σ·Vᵀ, keeping only the
+// `rank` non-zero singular values. The entries are decimals with the
+// requested precision.
+type svd struct {
+	Rank int        `json:"rank"`
+	U    [][]string `json:"u"`
+	S    []string   `json:"s"`
+	VT   [][]string `json:"vt"`
+}
+
+// Handles pinv requests by validating the supplied matrix of int
+// literals and returning its Moore-Penrose pseudoinverse. Accepts
+// rectangular and rank deficient matrices of up to maxPinvSize rows and
+// columns. The results are exact fractions unless the `prec` query
+// parameter asks for decimals. Expects the matrix CSV in the request
+// context.
+func handlePinv(w h.ResponseWriter, r *h.Request) {
+	prec, err := parseOptPrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(m) > maxPinvSize || ncols(m) > maxPinvSize {
+		h.Error(w, fmt.Sprintf("Error: matrix is limited to %dx%d", maxPinvSize, maxPinvSize), h.StatusBadRequest)
+
+		return
+	}
+
+	fmt.Fprint(w, rmtos(pinv(ratMatrix(m)), prec))
+}
+
+// Handles svd requests by validating the supplied matrix of int
+// literals and returning its compact singular value decomposition as
+// JSON (see svd), computed with `prec` significant digits. Accepts
+// rectangular matrices of up to maxSVDSize rows and columns. Expects
+// the matrix CSV in the request context.
+func handleSVD(w h.ResponseWriter, r *h.Request) {
+	prec, err := parsePrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok || !checkSVDSize(w, m) {
+		return
+	}
+
+	a := ratMatrix(m)
+	bits := precBits(prec)
+
+	// The rank is computed exactly, so we know which of the singular
+	// values are really zero.
+	rank := len(rref(cloneRat(a)))
+
+	sigma, u, v := svdInt(m, rank, bits)
+
+	res := svd{
+		Rank: rank,
+		U:    make([][]string, len(a)),
+		S:    make([]string, rank),
+		VT:   make([][]string, rank),
+	}
+
+	for i := range res.U {
+		res.U[i] = make([]string, rank)
+		for k := range rank {
+			res.U[i][k] = ftos(u[i][k], prec)
+		}
+	}
+
+	for k := range rank {
+		res.S[k] = ftos(sigma[k], prec)
+
+		res.VT[k] = make([]string, len(v))
+		for j := range v {
+			res.VT[k][j] = ftos(v[j][k], prec)
+		}
+	}
+
+	writeJSON(w, res)
+}
+
+// Rejects matrices with more than maxSVDSize rows or columns. Reports
+// errors to the user; the caller should return if the result is false.
+func checkSVDSize(w h.ResponseWriter, m [][]*big.Int) bool {
+	if len(m) > maxSVDSize || ncols(m) > maxSVDSize {
+		h.Error(w, fmt.Sprintf("Error: matrix is limited to %dx%d", maxSVDSize, maxSVDSize), h.StatusBadRequest)
+
+		return false
+	}
+
+	return true
+}
+
+// Returns the Moore-Penrose pseudoinverse of the rational matrix `a`
+// computed exactly from its rank factorization a = C·F, where F holds
+// the non-zero rows of the reduced row echelon form of `a` and C the
+// pivot columns of `a`:
+//
+//	a⁺ = Fᵀ·(F·Fᵀ)⁻¹·(CᵀC)⁻¹·Cᵀ
+func pinv(a [][]*big.Rat) [][]*big.Rat {
+	f := cloneRat(a)
+	pivots := rref(f)
+	f = f[:len(pivots)]
+
+	if len(pivots) == 0 {
+		// The pseudoinverse of a zero matrix is its (zero) transpose.
+		return newRatMatrix(ncols(a), len(a))
+	}
+
+	c := make([][]*big.Rat, len(a))
+	for i, row := range a {
+		c[i] = make([]*big.Rat, len(pivots))
+		for k, j := range pivots {
+			c[i][k] = row[j]
+		}
+	}
+
+	ft, ct := transposeRat(f), transposeRat(c)
+
+	// Both are full rank, so they're invertible.
+	ffInv, err1 := inverseRat(mulRat(f, ft))
+	ccInv, err2 := inverseRat(mulRat(ct, c))
+	if err1 != nil || err2 != nil {
+		panic("rank factorization is not full rank")
+	}
+
+	return mulRat(mulRat(ft, ffInv), mulRat(ccInv, ct))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecompHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"pinv-invertible",
+			handlePinv,
+			"",
+			[]byte("1,2\n3,4"),
+			"-2,1\n3/2,-1/2\n",
+			200,
+		},
+		{
+			"pinv-rank-deficient",
+			handlePinv,
+			"",
+			[]byte("1,2\n2,4"),
+			"1/25,2/25\n2/25,4/25\n",
+			200,
+		},
+		{
+			"pinv-rectangular",
+			handlePinv,
+			"",
+			[]byte("1,0\n0,1\n1,1"),
+			"2/3,-1/3,1/3\n-1/3,2/3,1/3\n",
+			200,
+		},
+		{
+			"pinv-decimals",
+			handlePinv,
+			"prec=3",
+			[]byte("3"),
+			"0.333\n",
+			200,
+		},
+		{
+			"pinv-zero",
+			handlePinv,
+			"",
+			[]byte("0,0,0\n0,0,0"),
+			"0,0\n0,0\n0,0\n",
+			200,
+		},
+		{
+			"svd",
+			handleSVD,
+			"prec=10",
+			[]byte("0,2\n3,0"),
+			`{"rank":2,"u":[["0","1"],["1","0"]],"s":["3","2"],"vt":[["1","0"],["0","1"]]}` + "\n",
+			200,
+		},
+		{
+			"svd-rank-deficient",
+			handleSVD,
+			"prec=10",
+			[]byte("3,4\n0,0"),
+			`{"rank":1,"u":[["1"],["0"]],"s":["5"],"vt":[["0.6","0.8"]]}` + "\n",
+			200,
+		},
+		{
+			"svd-nearly-parallel-columns",
+			handleSVD,
+			"prec=10",
+			[]byte("1000000000000000000000000000000,1000000000000000000000000000001\n1000000000000000000000000000000,1000000000000000000000000000000"),
+			`{"rank":2,"u":[["0.7071067812","-0.7071067812"],["0.7071067812","0.7071067812"]],"s":["2e+30","0.5"],` +
+				`"vt":[["0.7071067812","0.7071067812"],["0.7071067812","-0.7071067812"]]}` + "\n",
+			200,
+		},
+		{
+			"svd-badly-scaled",
+			handleSVD,
+			"prec=10",
+			[]byte("1000000000000000000000000000000,1\n1,1"),
+			`{"rank":2,"u":[["1","-1e-30"],["1e-30","1"]],"s":["1e+30","1"],"vt":[["1","1e-30"],["-1e-30","1"]]}` + "\n",
+			200,
+		},
+		{
+			"svd-empty-csv",
+			handleSVD,
+			"",
+			[]byte{},
+			`{"rank":0,"u":[],"s":[],"vt":[]}` + "\n",
+			200,
+		},
+		{
+			"pinv-too-large",
+			handlePinv,
+			"",
+			[]byte(strings.Repeat("1,", maxPinvSize) + "1"),
+			"Error: matrix is limited to 24x24\n",
+			400,
+		},
+		{
+			"svd-too-large",
+			handleSVD,
+			"",
+			[]byte(strings.Repeat("1,", maxSVDSize) + "1"),
+			"Error: matrix is limited to 16x16\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			handleSVD,
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := rectApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"math/big"
+	"slices"
+)
+
+const (
+	// Caps the number of Jacobi sweeps. Convergence is quadratic, so
+	// this is only ever reached on pathological inputs.
+	maxJacobiSweeps = 64
+
+	// The extra bits of precision of the Jacobi method, which absorb
+	// the rounding errors of the rotations.
+	jacobiGuardBits = 32
+
+	// Caps the working precision of the Jacobi method, which svdInt
+	// otherwise raises with the size of the entries.
+	maxJacobiBits = 1 << 12
+
+	// Caps the rows and columns of the matrices decomposed with the
+	// Jacobi method; a sweep takes O(m·n²) big.Float operations.
+	maxSVDSize = 16
+)
+
+// Converts the rational matrix `a` to a big.Float one with `bits`
+// bits of precision.
+func floatMatrix(a [][]*big.Rat, bits uint) [][]*big.Float {
+	out := make([][]*big.Float, len(a))
+
+	for i, row := range a {
+		out[i] = make([]*big.Float, len(row))
+		for j, x := range row {
+			out[i][j] = new(big.Float).SetPrec(bits).SetRat(x)
+		}
+	}
+
+	return out
+}
+
+// Returns a new big.Float with `bits` bits of precision set to `x`.
+func newFloat(bits uint, x float64) *big.Float {
+	return new(big.Float).SetPrec(bits).SetFloat64(x)
+}
+
+// Computes the singular value decomposition a = U·Σ·Vᵀ of the m x n
+// matrix `a` with the one-sided Jacobi method at `bits` bits of
+// precision: pairs of columns of `a` are rotated until they're all
+// orthogonal, accumulating the rotations in V. Works on `a` itself
+// rather than on aᵀa, which would square its condition number.
+//
+// Returns the n singular values in descending order, along with the
+// m x n matrix U and the n x n matrix V whose columns are the
+// corresponding singular vectors. The columns of U for the zero
+// singular values are zero. Overwrites `a`.
+func jacobiSVD(a [][]*big.Float, bits uint) ([]*big.Float, [][]*big.Float, [][]*big.Float) {
+	n := ncols(a)
+
+	v := make([][]*big.Float, n)
+	for i := range v {
+		v[i] = make([]*big.Float, n)
+		for j := range v[i] {
+			v[i][j] = newFloat(bits, 0)
+		}
+		v[i][i].SetInt64(1)
+	}
+
+	// Columns p and q count as orthogonal once their dot product is
+	// negligible relative to the product of their norms.
+	eps := new(big.Float).SetPrec(bits).SetMantExp(big.NewFloat(1), -int(bits))
+
+	one := newFloat(bits, 1)
+	alpha, beta, gamma := newFloat(bits, 0), newFloat(bits, 0), newFloat(bits, 0)
+	zeta, t, c, s := newFloat(bits, 0), newFloat(bits, 0), newFloat(bits, 0), newFloat(bits, 0)
+	x, y := newFloat(bits, 0), newFloat(bits, 0)
+
+	// Rotates columns p and q of `m`.
+	rotate := func(m [][]*big.Float, p, q int) {
+		for _, row := range m {
+			ap, aq := row[p], row[q]
+
+			// ap, aq = c·ap - s·aq, s·ap + c·aq
+			x.Mul(c, ap)
+			x.Sub(x, y.Mul(s, aq))
+			y.Mul(s, ap)
+			aq.Mul(c, aq)
+			aq.Add(aq, y)
+			ap.Set(x)
+		}
+	}
+
+	for sweep := 0; sweep < maxJacobiSweeps; sweep++ {
+		rotated := false
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				alpha.SetInt64(0)
+				beta.SetInt64(0)
+				gamma.SetInt64(0)
+				for _, row := range a {
+					alpha.Add(alpha, x.Mul(row[p], row[p]))
+					beta.Add(beta, x.Mul(row[q], row[q]))
+					gamma.Add(gamma, x.Mul(row[p], row[q]))
+				}
+
+				// |γ| ≤ eps·sqrt(αβ)
+				y.Mul(alpha, beta)
+				y.Sqrt(y)
+				if x.Abs(gamma).Cmp(y.Mul(y, eps)) <= 0 {
+					continue
+				}
+				rotated = true
+
+				// zeta = (β - α) / 2γ
+				zeta.Sub(beta, alpha)
+				zeta.Quo(zeta, x.Mul(gamma, newFloat(bits, 2)))
+
+				// t = sign(zeta) / (|zeta| + sqrt(zeta² + 1))
+				t.Mul(zeta, zeta)
+				t.Add(t, one)
+				t.Sqrt(t)
+				t.Add(t, x.Abs(zeta))
+				t.Quo(one, t)
+				if zeta.Sign() < 0 {
+					t.Neg(t)
+				}
+
+				// c = 1 / sqrt(t² + 1), s = t·c
+				c.Mul(t, t)
+				c.Add(c, one)
+				c.Sqrt(c)
+				c.Quo(one, c)
+				s.Mul(t, c)
+
+				rotate(a, p, q)
+				rotate(v, p, q)
+			}
+		}
+
+		if !rotated {
+			break
+		}
+	}
+
+	// The singular values are the norms of the columns, and the left
+	// singular vectors the normalized columns.
+	sigma := make([]*big.Float, n)
+	for j := range sigma {
+		sigma[j] = newFloat(bits, 0)
+		for _, row := range a {
+			sigma[j].Add(sigma[j], x.Mul(row[j], row[j]))
+		}
+		sigma[j].Sqrt(sigma[j])
+
+		for _, row := range a {
+			if sigma[j].Sign() == 0 {
+				row[j].SetInt64(0)
+			} else {
+				row[j].Quo(row[j], sigma[j])
+			}
+		}
+	}
+
+	// Sort the singular triplets by descending singular value.
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		return sigma[j].Cmp(sigma[i])
+	})
+
+	vals := make([]*big.Float, n)
+	u, vecs := make([][]*big.Float, len(a)), make([][]*big.Float, n)
+	for i := range u {
+		u[i] = make([]*big.Float, n)
+	}
+	for i := range vecs {
+		vecs[i] = make([]*big.Float, n)
+	}
+	for k, j := range order {
+		vals[k] = sigma[j]
+		for i := range a {
+			u[i][k] = a[i][j]
+		}
+		for i := range v {
+			vecs[i][k] = v[i][j]
+		}
+	}
+
+	return vals, u, vecs
+}
+
+// Returns the singular value decomposition of the integer matrix `m`
+// of rank `rank` (see jacobiSVD), with the singular values accurate to
+// about `bits` bits.
+//
+// Rounding errors perturb all the singular values by about 2⁻ᵖ·σ₁ at p
+// bits of precision, so the smallest non-zero one, σᵣ, loses about
+// log₂(σ₁/σᵣ) bits. The decomposition is computed again at a higher
+// precision until σᵣ has enough of them left. Since the sum of the
+// squares of the r x r minors of `m` is a non-zero integer, σ₁/σᵣ is at
+// most the product of 1 + ‖cⱼ‖² over the columns cⱼ of `m`, which
+// bounds the precision needed. So does maxJacobiBits, past which the
+// smallest singular values may lose accuracy.
+func svdInt(m [][]*big.Int, rank int, bits uint) ([]*big.Float, [][]*big.Float, [][]*big.Float) {
+	work, limit := bits+jacobiGuardBits, bits+jacobiGuardBits
+	for j := range ncols(m) {
+		sq := big.NewInt(1)
+		for _, row := range m {
+			sq.Add(sq, new(big.Int).Mul(row[j], row[j]))
+		}
+		limit += uint(sq.BitLen())
+	}
+	limit = max(min(limit, maxJacobiBits), work)
+
+	for {
+		sigma, u, v := jacobiSVD(floatMatrix(ratMatrix(m), work), work)
+		if rank == 0 || work >= limit {
+			return sigma, u, v
+		}
+
+		if s := sigma[rank-1]; s.Sign() > 0 {
+			lost := sigma[0].MantExp(nil) - s.MantExp(nil)
+			if uint(lost)+bits+jacobiGuardBits <= work {
+				return sigma, u, v
+			}
+		}
+
+		work = min(2*work, limit)
+	}
+}
+
+// Returns the singular values of the rational matrix `a` in descending
+// order, computed at `bits` bits of precision. The largest one is
+// accurate to about `bits` bits; the others are only accurate relative
+// to it.
+func singularValues(a [][]*big.Rat, bits uint) []*big.Float {
+	work := min(bits+jacobiGuardBits, maxJacobiBits)
+	sigma, _, _ := jacobiSVD(floatMatrix(a, work), work)
+
+	return sigma
+}
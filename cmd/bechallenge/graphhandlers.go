@@ -0,0 +1,638 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The graph handlers treat the square matrix as the adjacency matrix
+// of a directed graph: a non-zero (i, j) entry is an edge from node i
+// to node j weighted with the entry. Nodes are numbered from 1 in the
+// responses.
+
+const (
+	// Caps the number of nodes of the graphs handled with the O(n³)
+	// Floyd-Warshall and Warshall algorithms.
+	maxGraphNodes = 300
+
+	// Caps the number of nodes of maxflow requests; the Edmonds-Karp
+	// algorithm takes O(V·E²) steps, or O(n⁵) on a dense graph.
+	maxFlowNodes = 200
+)
+
+// An edge in the JSON edge list responses. Weight holds the edge
+// weight or path length, if the response has one, as a string so that
+// JSON clients don't lose precision.
+type edge struct {
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Weight string `json:"weight,omitempty"`
+}
+
+// Gets the response format from the `format` query parameter: matrix
+// (default) or json.
+func graphFormat(w h.ResponseWriter, r *h.Request) (json, ok bool) {
+	switch f := r.FormValue("format"); f {
+	case "", "matrix":
+		return false, true
+	case "json":
+		return true, true
+	default:
+		h.Error(w, fmt.Sprintf("Error: unknown format %q", f), h.StatusBadRequest)
+
+		return false, false
+	}
+}
+
+// Handles shortest-paths requests by validating the supplied adjacency
+// matrix of int literals and returning the lengths of the shortest
+// paths between all pairs of nodes, computed with the Floyd-Warshall
+// algorithm. Negative weights are fine, but negative cycles are
+// reported as an error. Responds with the distance matrix, where
+// unreachable pairs are "inf", or with a JSON list of the reachable
+// pairs if `format=json`. The graph is limited to maxGraphNodes nodes.
+// Expects the matrix CSV in the request context.
+func handleShortestPaths(w h.ResponseWriter, r *h.Request) {
+	asJSON, ok := graphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok || !checkGraphNodes(w, m) {
+		return
+	}
+
+	dist, err := shortestPaths(m)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	if asJSON {
+		edges := []edge{}
+		for i, row := range dist {
+			for j, d := range row {
+				if d != nil && i != j {
+					edges = append(edges, edge{i + 1, j + 1, d.String()})
+				}
+			}
+		}
+
+		writeJSON(w, edges)
+
+		return
+	}
+
+	var resp string
+	for _, row := range dist {
+		ss := make([]string, len(row))
+		for j, d := range row {
+			if d == nil {
+				ss[j] = "inf"
+			} else {
+				ss[j] = d.String()
+			}
+		}
+
+		resp += strings.Join(ss, ",") + "\n"
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if len(resp) == 0 {
+		resp += "\n"
+	}
+
+	fmt.Fprint(w, resp)
+}
+
+// Handles components requests by validating the supplied adjacency
+// matrix of int literals and returning its connected components: weakly
+// connected ones by default, strongly connected ones with
+// `type=strong`. Responds with a line with the component number of
+// each node, or with a JSON list of the components (lists of nodes) if
+// `format=json`. Components are numbered in the order of their
+// smallest node. The graph is limited to maxGraphNodes nodes. Expects
+// the matrix CSV in the request context.
+func handleComponents(w h.ResponseWriter, r *h.Request) {
+	asJSON, ok := graphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	typ := r.FormValue("type")
+	if typ != "" && typ != "weak" && typ != "strong" {
+		h.Error(w, "Error: type must be one of weak or strong", h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok || !checkGraphNodes(w, m) {
+		return
+	}
+
+	// Nodes i and j are in the same weak component iff they're
+	// connected ignoring the direction of the edges, and in the same
+	// strong one iff they're reachable from each other.
+	reach := closure(m, typ != "strong")
+	labels := make([]int, len(m))
+	comps := [][]int{}
+	for i := range m {
+		if labels[i] != 0 {
+			continue
+		}
+
+		comp := []int{}
+		for j := i; j < len(m); j++ {
+			if j == i || (reach[i][j] && reach[j][i]) {
+				labels[j] = len(comps) + 1
+				comp = append(comp, j+1)
+			}
+		}
+		comps = append(comps, comp)
+	}
+
+	if asJSON {
+		writeJSON(w, comps)
+
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, itos(intsToBig(labels)), "\n")
+}
+
+// Handles transitive-closure requests by validating the supplied
+// adjacency matrix of int literals and returning its transitive
+// closure: a 0/1 matrix with a 1 for every pair of nodes connected by a
+// path of one or more edges. Responds with a JSON edge list instead if
+// `format=json`. The graph is limited to maxGraphNodes nodes. Expects
+// the matrix CSV in the request context.
+func handleTransitiveClosure(w h.ResponseWriter, r *h.Request) {
+	asJSON, ok := graphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok || !checkGraphNodes(w, m) {
+		return
+	}
+
+	reach := closure(m, false)
+
+	if asJSON {
+		edges := []edge{}
+		for i, row := range reach {
+			for j, ok := range row {
+				if ok {
+					edges = append(edges, edge{From: i + 1, To: j + 1})
+				}
+			}
+		}
+
+		writeJSON(w, edges)
+
+		return
+	}
+
+	out := make([][]*big.Int, len(reach))
+	for i, row := range reach {
+		out[i] = make([]*big.Int, len(row))
+		for j, ok := range row {
+			out[i][j] = big.NewInt(0)
+			if ok {
+				out[i][j].SetInt64(1)
+			}
+		}
+	}
+
+	fmt.Fprint(w, mtos(out))
+}
+
+// Handles toposort requests by validating the supplied adjacency matrix
+// of int literals and returning a topological order of its nodes (the
+// smallest available node first). Responds with a one line list of
+// nodes, or with a JSON list if `format=json`. Graphs with cycles are
+// reported as an error. Expects the matrix CSV in the request context.
+func handleToposort(w h.ResponseWriter, r *h.Request) {
+	asJSON, ok := graphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	// Kahn's algorithm.
+	indeg := make([]int, len(m))
+	for _, row := range m {
+		for j, d := range row {
+			if d.Sign() != 0 {
+				indeg[j]++
+			}
+		}
+	}
+
+	order := []int{}
+	done := make([]bool, len(m))
+	for len(order) < len(m) {
+		next := -1
+		for i := range m {
+			if !done[i] && indeg[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			h.Error(w, "Error: graph contains a cycle", h.StatusBadRequest)
+
+			return
+		}
+
+		done[next] = true
+		order = append(order, next+1)
+		for j, d := range m[next] {
+			if d.Sign() != 0 {
+				indeg[j]--
+			}
+		}
+	}
+
+	if asJSON {
+		writeJSON(w, order)
+
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, itos(intsToBig(order)), "\n")
+}
+
+// The degrees of a node; self-loops count towards both.
+type degree struct {
+	Node int `json:"node"`
+	In   int `json:"in"`
+	Out  int `json:"out"`
+}
+
+// Handles degree requests by validating the supplied adjacency matrix
+// of int literals and returning the in- and out-degree of each node.
+// Responds with an "in,out" line per node, or with a JSON list if
+// `format=json`. Expects the matrix CSV in the request context.
+func handleDegree(w h.ResponseWriter, r *h.Request) {
+	asJSON, ok := graphFormat(w, r)
+	if !ok {
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	degs := make([]degree, len(m))
+	for i, row := range m {
+		degs[i].Node = i + 1
+		for j, d := range row {
+			if d.Sign() != 0 {
+				degs[i].Out++
+				degs[j].In++
+			}
+		}
+	}
+
+	if asJSON {
+		writeJSON(w, degs)
+
+		return
+	}
+
+	out := make([][]*big.Int, len(degs))
+	for i, d := range degs {
+		out[i] = intsToBig([]int{d.In, d.Out})
+	}
+
+	fmt.Fprint(w, mtos(out))
+}
+
+// Returns the matrix of shortest path lengths of the graph with the
+// adjacency matrix `m`. Unreachable pairs are nil.
+func shortestPaths(m [][]*big.Int) ([][]*big.Int, error) {
+	n := len(m)
+
+	dist := make([][]*big.Int, n)
+	for i, row := range m {
+		dist[i] = make([]*big.Int, n)
+		for j, d := range row {
+			if d.Sign() != 0 {
+				dist[i][j] = new(big.Int).Set(d)
+			}
+		}
+
+		// Staying put is free, unless a negative self-loop makes it
+		// a negative cycle.
+		if dist[i][i] == nil || dist[i][i].Sign() > 0 {
+			dist[i][i] = new(big.Int)
+		}
+	}
+
+	t := new(big.Int)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if dist[i][k] == nil {
+				continue
+			}
+
+			for j := 0; j < n; j++ {
+				if dist[k][j] == nil {
+					continue
+				}
+
+				t.Add(dist[i][k], dist[k][j])
+				if dist[i][j] == nil {
+					dist[i][j] = new(big.Int).Set(t)
+				} else if t.Cmp(dist[i][j]) < 0 {
+					dist[i][j].Set(t)
+				}
+			}
+		}
+	}
+
+	for i := range dist {
+		if dist[i][i].Sign() < 0 {
+			return nil, errors.New("graph contains a negative cycle")
+		}
+	}
+
+	return dist, nil
+}
+
+// Returns the reachability matrix of the graph with the adjacency
+// matrix `m` (paths of one or more edges), computed with Warshall's
+// algorithm. Ignores the direction of the edges if `undirected`.
+func closure(m [][]*big.Int, undirected bool) [][]bool {
+	n := len(m)
+
+	reach := make([][]bool, n)
+	for i := range reach {
+		reach[i] = make([]bool, n)
+	}
+	for i, row := range m {
+		for j, d := range row {
+			if d.Sign() != 0 {
+				reach[i][j] = true
+				if undirected {
+					reach[j][i] = true
+				}
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if !reach[i][k] {
+				continue
+			}
+
+			for j := 0; j < n; j++ {
+				if reach[k][j] {
+					reach[i][j] = true
+				}
+			}
+		}
+	}
+
+	return reach
+}
+
+// Rejects graphs with more than maxGraphNodes nodes. Reports errors to
+// the user; the caller should return if the result is false.
+func checkGraphNodes(w h.ResponseWriter, m [][]*big.Int) bool {
+	if len(m) > maxGraphNodes {
+		h.Error(w, fmt.Sprintf("Error: the graph is limited to %d nodes", maxGraphNodes), h.StatusBadRequest)
+
+		return false
+	}
+
+	return true
+}
+
+// Converts the int slice `in` to a big.Int slice.
+func intsToBig(in []int) []*big.Int {
+	out := make([]*big.Int, len(in))
+	for i, d := range in {
+		out[i] = big.NewInt(int64(d))
+	}
+
+	return out
+}
+
+// The result of a maximum flow computation. The flows are strings so
+// that JSON clients don't lose precision.
+type maxFlow struct {
+	Value string `json:"value"`
+	// The flow along each edge.
+	Flow [][]string `json:"flow"`
+	// The minimum cut: the nodes reachable from the source in the
+	// residual graph, and the rest.
+	SourceSide []int `json:"sourceSide"`
+	SinkSide   []int `json:"sinkSide"`
+}
+
+// Handles maxflow requests by validating the supplied capacity matrix
+// of int literals and returning the maximum flow from the `source` to
+// the `sink` node (query parameters, 1-based) as JSON (see maxFlow).
+// Uses the Edmonds-Karp algorithm, whose running time doesn't depend
+// on the magnitude of the capacities. The graph is limited to
+// maxFlowNodes nodes. Expects the matrix CSV in the request context.
+func handleMaxFlow(w h.ResponseWriter, r *h.Request) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(m) > maxFlowNodes {
+		h.Error(w, fmt.Sprintf("Error: the graph is limited to %d nodes", maxFlowNodes), h.StatusBadRequest)
+
+		return
+	}
+
+	s, err1 := strconv.Atoi(r.FormValue("source"))
+	t, err2 := strconv.Atoi(r.FormValue("sink"))
+	if err1 != nil || err2 != nil || s < 1 || s > len(m) || t < 1 || t > len(m) || s == t {
+		h.Error(w, "Error: source and sink must be distinct nodes", h.StatusBadRequest)
+
+		return
+	}
+
+	for _, row := range m {
+		for _, d := range row {
+			if d.Sign() < 0 {
+				h.Error(w, "Error: capacities must be non-negative", h.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	writeJSON(w, edmondsKarp(m, s-1, t-1))
+}
+
+// Returns the maximum flow from node `s` to node `t` in the graph with
+// the capacity matrix `m`.
+func edmondsKarp(m [][]*big.Int, s, t int) maxFlow {
+	n := len(m)
+
+	// Residual capacities.
+	res := make([][]*big.Int, n)
+	for i, row := range m {
+		res[i] = make([]*big.Int, n)
+		for j, d := range row {
+			res[i][j] = new(big.Int).Set(d)
+		}
+	}
+
+	value := new(big.Int)
+	var reached []bool
+	for {
+		// Find the shortest augmenting path with a BFS.
+		prev := make([]int, n)
+		reached = make([]bool, n)
+		reached[s] = true
+		queue := []int{s}
+		for len(queue) > 0 && !reached[t] {
+			u := queue[0]
+			queue = queue[1:]
+
+			for v := 0; v < n; v++ {
+				if !reached[v] && res[u][v].Sign() > 0 {
+					reached[v], prev[v] = true, u
+					queue = append(queue, v)
+				}
+			}
+		}
+		if !reached[t] {
+			break
+		}
+
+		// Push the bottleneck capacity along the path.
+		push := new(big.Int).Set(res[prev[t]][t])
+		for v := t; v != s; v = prev[v] {
+			if res[prev[v]][v].Cmp(push) < 0 {
+				push.Set(res[prev[v]][v])
+			}
+		}
+		for v := t; v != s; v = prev[v] {
+			res[prev[v]][v].Sub(res[prev[v]][v], push)
+			res[v][prev[v]].Add(res[v][prev[v]], push)
+		}
+		value.Add(value, push)
+	}
+
+	// The flow along an edge is what's been used of its capacity, net
+	// of any flow in the opposite direction.
+	flow := make([][]*big.Int, n)
+	for i := range flow {
+		flow[i] = make([]*big.Int, n)
+		for j := range flow[i] {
+			f := new(big.Int).Sub(m[i][j], res[i][j])
+			if f.Sign() < 0 {
+				f.SetInt64(0)
+			}
+			flow[i][j] = f
+		}
+	}
+
+	mf := maxFlow{Value: value.String(), Flow: imstrings(flow), SourceSide: []int{}, SinkSide: []int{}}
+	for i, ok := range reached {
+		if ok {
+			mf.SourceSide = append(mf.SourceSide, i+1)
+		} else {
+			mf.SinkSide = append(mf.SinkSide, i+1)
+		}
+	}
+
+	return mf
+}
+
+// A minimum spanning tree (or forest, if the graph isn't connected).
+// The weight is a string so that JSON clients don't lose precision.
+type spanningTree struct {
+	Edges     []edge `json:"edges"`
+	Weight    string `json:"weight"`
+	Connected bool   `json:"connected"`
+}
+
+// Handles mst requests by validating the supplied symmetric weight
+// matrix of int literals and returning the minimum spanning tree of
+// the undirected graph as JSON (see spanningTree). Uses Kruskal's
+// algorithm. Expects the matrix CSV in the request context.
+func handleMST(w h.ResponseWriter, r *h.Request) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	// The edges (i, j) with i < j, and their weights.
+	type weightedEdge struct {
+		i, j   int
+		weight *big.Int
+	}
+
+	var edges []weightedEdge
+	for i, row := range m {
+		for j, d := range row {
+			if d.Cmp(m[j][i]) != 0 {
+				h.Error(w, "Error: matrix must be symmetric", h.StatusBadRequest)
+
+				return
+			}
+			if j > i && d.Sign() != 0 {
+				edges = append(edges, weightedEdge{i, j, d})
+			}
+		}
+	}
+
+	slices.SortStableFunc(edges, func(a, b weightedEdge) int {
+		return a.weight.Cmp(b.weight)
+	})
+
+	// Union-find over the nodes.
+	parent := make([]int, len(m))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	tree := spanningTree{Edges: []edge{}}
+	weight := new(big.Int)
+	for _, e := range edges {
+		a, b := find(e.i), find(e.j)
+		if a == b {
+			continue
+		}
+
+		parent[a] = b
+		tree.Edges = append(tree.Edges, edge{e.i + 1, e.j + 1, e.weight.String()})
+		weight.Add(weight, e.weight)
+	}
+	tree.Weight = weight.String()
+	tree.Connected = len(tree.Edges) == max(len(m)-1, 0)
+
+	writeJSON(w, tree)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGraphHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"shortest-paths",
+			handleShortestPaths,
+			"",
+			[]byte("0,4,1\n0,0,0\n0,2,0"),
+			"0,3,1\ninf,0,inf\ninf,2,0\n",
+			200,
+		},
+		{
+			"shortest-paths-negative-weights",
+			handleShortestPaths,
+			"",
+			[]byte("0,4,0\n0,0,-2\n0,0,0"),
+			"0,4,2\ninf,0,-2\ninf,inf,0\n",
+			200,
+		},
+		{
+			"shortest-paths-json",
+			handleShortestPaths,
+			"format=json",
+			[]byte("0,12345678901234567890\n0,0"),
+			`[{"from":1,"to":2,"weight":"12345678901234567890"}]` + "\n",
+			200,
+		},
+		{
+			"shortest-paths-negative-cycle",
+			handleShortestPaths,
+			"",
+			[]byte("0,1\n-2,0"),
+			"Error: graph contains a negative cycle\n",
+			400,
+		},
+		{
+			"shortest-paths-empty-csv",
+			handleShortestPaths,
+			"",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"shortest-paths-too-many-nodes",
+			handleShortestPaths,
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxGraphNodes)+"1\n", maxGraphNodes+1)),
+			"Error: the graph is limited to 300 nodes\n",
+			400,
+		},
+		{
+			"components-weak",
+			handleComponents,
+			"",
+			[]byte("0,1,0,0\n0,0,0,0\n0,0,0,1\n0,0,0,0"),
+			"1,1,2,2\n",
+			200,
+		},
+		{
+			"components-strong",
+			handleComponents,
+			"type=strong&format=json",
+			[]byte("0,1,0\n1,0,1\n0,0,0"),
+			"[[1,2],[3]]\n",
+			200,
+		},
+		{
+			"transitive-closure-too-many-nodes",
+			handleTransitiveClosure,
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxGraphNodes)+"1\n", maxGraphNodes+1)),
+			"Error: the graph is limited to 300 nodes\n",
+			400,
+		},
+		{
+			"components-invalid-type",
+			handleComponents,
+			"type=biconnected",
+			[]byte("0"),
+			"Error: type must be one of weak or strong\n",
+			400,
+		},
+		{
+			"transitive-closure",
+			handleTransitiveClosure,
+			"",
+			[]byte("0,1,0\n0,0,1\n0,0,0"),
+			"0,1,1\n0,0,1\n0,0,0\n",
+			200,
+		},
+		{
+			"transitive-closure-json",
+			handleTransitiveClosure,
+			"format=json",
+			[]byte("0,1\n1,0"),
+			`[{"from":1,"to":1},{"from":1,"to":2},{"from":2,"to":1},{"from":2,"to":2}]` + "\n",
+			200,
+		},
+		{
+			"toposort",
+			handleToposort,
+			"",
+			[]byte("0,0,0\n1,0,0\n1,1,0"),
+			"3,2,1\n",
+			200,
+		},
+		{
+			"toposort-json",
+			handleToposort,
+			"format=json",
+			[]byte("0,1\n0,0"),
+			"[1,2]\n",
+			200,
+		},
+		{
+			"toposort-cycle",
+			handleToposort,
+			"",
+			[]byte("0,1\n1,0"),
+			"Error: graph contains a cycle\n",
+			400,
+		},
+		{
+			"degree",
+			handleDegree,
+			"",
+			[]byte("0,1,1\n0,0,1\n0,0,0"),
+			"0,2\n1,1\n2,0\n",
+			200,
+		},
+		{
+			"degree-json",
+			handleDegree,
+			"format=json",
+			[]byte("1,1\n0,0"),
+			`[{"node":1,"in":1,"out":2},{"node":2,"in":1,"out":0}]` + "\n",
+			200,
+		},
+		{
+			"unknown-format",
+			handleDegree,
+			"format=dot",
+			[]byte("0"),
+			"Error: unknown format \"dot\"\n",
+			400,
+		},
+		{
+			"maxflow",
+			handleMaxFlow,
+			"source=1&sink=4",
+			[]byte("0,3,2,0\n0,0,1,2\n0,0,0,3\n0,0,0,0"),
+			`{"value":"5","flow":[["0","3","2","0"],["0","0","1","2"],["0","0","0","3"],["0","0","0","0"]],` +
+				`"sourceSide":[1],"sinkSide":[2,3,4]}` + "\n",
+			200,
+		},
+		{
+			"maxflow-min-cut",
+			handleMaxFlow,
+			"source=1&sink=3",
+			[]byte("0,12345678901234567890,0\n0,0,1\n0,0,0"),
+			`{"value":"1","flow":[["0","1","0"],["0","0","1"],["0","0","0"]],"sourceSide":[1,2],"sinkSide":[3]}` + "\n",
+			200,
+		},
+		{
+			"maxflow-too-many-nodes",
+			handleMaxFlow,
+			"source=1&sink=2",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxFlowNodes)+"1\n", maxFlowNodes+1)),
+			"Error: the graph is limited to 200 nodes\n",
+			400,
+		},
+		{
+			"maxflow-same-source-and-sink",
+			handleMaxFlow,
+			"source=1&sink=1",
+			[]byte("0,1\n0,0"),
+			"Error: source and sink must be distinct nodes\n",
+			400,
+		},
+		{
+			"maxflow-negative-capacity",
+			handleMaxFlow,
+			"source=1&sink=2",
+			[]byte("0,-1\n0,0"),
+			"Error: capacities must be non-negative\n",
+			400,
+		},
+		{
+			"mst",
+			handleMST,
+			"",
+			[]byte("0,1,3\n1,0,2\n3,2,0"),
+			`{"edges":[{"from":1,"to":2,"weight":"1"},{"from":2,"to":3,"weight":"2"}],` +
+				`"weight":"3","connected":true}` + "\n",
+			200,
+		},
+		{
+			"mst-forest",
+			handleMST,
+			"",
+			[]byte("0,-5,0\n-5,0,0\n0,0,0"),
+			`{"edges":[{"from":1,"to":2,"weight":"-5"}],"weight":"-5","connected":false}` + "\n",
+			200,
+		},
+		{
+			"mst-not-symmetric",
+			handleMST,
+			"",
+			[]byte("0,1\n2,0"),
+			"Error: matrix must be symmetric\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			handleDegree,
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := webApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+	"strconv"
+)
+
+// The connected regions of a grid.
+type gridRegions struct {
+	// The region label of each cell, numbered from 1 in the order the
+	// regions are first met row by row, or 0 for background cells.
+	Labels  [][]int  `json:"labels"`
+	Regions []region `json:"regions"`
+}
+
+// A connected region of a grid, with its bounding box (the rows and
+// columns, numbered from 1, it spans). Value is the value shared by its
+// cells, unless regions are formed by nonzero cells, as a string so
+// that JSON clients don't lose precision.
+type region struct {
+	Label  int    `json:"label"`
+	Value  string `json:"value,omitempty"`
+	Size   int    `json:"size"`
+	Top    int    `json:"top"`
+	Left   int    `json:"left"`
+	Bottom int    `json:"bottom"`
+	Right  int    `json:"right"`
+}
+
+// The offsets of the neighbours of a cell with 4- and 8-connectivity.
+var neighbours = map[string][][2]int{
+	"4": {{-1, 0}, {0, -1}, {0, 1}, {1, 0}},
+	"8": {{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}},
+}
+
+// Handles region requests by validating the supplied grid of int
+// literals and returning its connected regions as JSON (see
+// gridRegions). By default a region is a maximal set of cells with
+// equal values; with `mode=nonzero` it's an island of nonzero cells,
+// whatever their values, and the zero cells are background. The
+// `connectivity` query parameter selects whether cells touch through
+// their sides only (4, the default) or their corners too (8). Accepts
+// rectangular grids. Expects the matrix CSV in the request context.
+func handleRegions(w h.ResponseWriter, r *h.Request) {
+	offsets, ok := parseConnectivity(w, r)
+	if !ok {
+		return
+	}
+
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = "equal"
+	}
+	if mode != "equal" && mode != "nonzero" {
+		h.Error(w, "Error: mode must be one of equal or nonzero", h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	res := gridRegions{Labels: make([][]int, len(m)), Regions: []region{}}
+	for i, row := range m {
+		res.Labels[i] = make([]int, len(row))
+	}
+
+	for i, row := range m {
+		for j, d := range row {
+			if res.Labels[i][j] != 0 || mode == "nonzero" && d.Sign() == 0 {
+				continue
+			}
+
+			reg := region{Label: len(res.Regions) + 1, Top: i + 1, Left: j + 1, Bottom: i + 1, Right: j + 1}
+			if mode == "equal" {
+				reg.Value = d.String()
+			}
+
+			same := func(e *big.Int) bool {
+				if mode == "nonzero" {
+					return e.Sign() != 0
+				}
+
+				return e.Cmp(d) == 0
+			}
+
+			for _, c := range floodRegion(m, i, j, offsets, same) {
+				res.Labels[c[0]][c[1]] = reg.Label
+				reg.Size++
+				reg.Top, reg.Bottom = min(reg.Top, c[0]+1), max(reg.Bottom, c[0]+1)
+				reg.Left, reg.Right = min(reg.Left, c[1]+1), max(reg.Right, c[1]+1)
+			}
+
+			res.Regions = append(res.Regions, reg)
+		}
+	}
+
+	writeJSON(w, res)
+}
+
+// Handles flood fill requests by validating the supplied grid of int
+// literals and returning it with the region of equal values around the
+// cell at the `row` and `col` query parameters (numbered from 1)
+// replaced with the `value` query parameter, like the paint bucket of
+// an image editor. The `connectivity` query parameter is as for
+// handleRegions. Accepts rectangular grids. Expects the matrix CSV in
+// the request context.
+func handleFloodFill(w h.ResponseWriter, r *h.Request) {
+	offsets, ok := parseConnectivity(w, r)
+	if !ok {
+		return
+	}
+
+	vs, err := atoi([]string{r.FormValue("value")})
+	if err != nil {
+		h.Error(w, "Error: value must be an integer", h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	pos := make([]int, 2)
+	for k, p := range []struct {
+		name string
+		size int
+	}{{"row", len(m)}, {"col", ncols(m)}} {
+		pos[k], err = strconv.Atoi(r.FormValue(p.name))
+		if err != nil || pos[k] < 1 || pos[k] > p.size {
+			h.Error(w, fmt.Sprintf("Error: %s must be an integer between 1 and %d", p.name, p.size),
+				h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	i, j := pos[0]-1, pos[1]-1
+	d := m[i][j]
+	if d.Cmp(vs[0]) != 0 {
+		for _, c := range floodRegion(m, i, j, offsets, func(e *big.Int) bool { return e.Cmp(d) == 0 }) {
+			m[c[0]][c[1]] = vs[0]
+		}
+	}
+
+	streamMatrix(w, len(m), func(i int) []*big.Int { return m[i] })
+}
+
+// Parses the `connectivity` query parameter (4 by default) into the
+// offsets of the neighbours of a cell.
+func parseConnectivity(w h.ResponseWriter, r *h.Request) (offsets [][2]int, ok bool) {
+	s := r.FormValue("connectivity")
+	if s == "" {
+		s = "4"
+	}
+
+	offsets, ok = neighbours[s]
+	if !ok {
+		h.Error(w, "Error: connectivity must be one of 4 or 8", h.StatusBadRequest)
+	}
+
+	return offsets, ok
+}
+
+// Returns the cells of the region of the grid `m` around the cell (i,
+// j): the cells reachable from it through neighbours at `offsets` whose
+// values satisfy `same`. The search keeps its own queue, so the size of
+// the region isn't limited by the stack.
+func floodRegion(m [][]*big.Int, i, j int, offsets [][2]int, same func(*big.Int) bool) [][2]int {
+	seen := make(map[[2]int]bool)
+	seen[[2]int{i, j}] = true
+	cells := [][2]int{{i, j}}
+
+	// The cells found so far serve as the queue.
+	for k := 0; k < len(cells); k++ {
+		for _, o := range offsets {
+			c := [2]int{cells[k][0] + o[0], cells[k][1] + o[1]}
+			if c[0] < 0 || c[0] >= len(m) || c[1] < 0 || c[1] >= len(m[c[0]]) || seen[c] {
+				continue
+			}
+			if !same(m[c[0]][c[1]]) {
+				continue
+			}
+
+			seen[c] = true
+			cells = append(cells, c)
+		}
+	}
+
+	return cells
+}
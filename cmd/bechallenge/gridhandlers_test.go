@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHandleRegions(t *testing.T) {
+	// Two islands touching at a corner, and a lake inside the second.
+	tiles := []byte("1,1,0,0\n0,0,2,2\n0,0,2,0\n0,0,2,2")
+
+	tests := []queryTestCase{
+		{
+			"equal",
+			"",
+			[]byte("1,1,2\n3,1,2"),
+			`{"labels":[[1,1,2],[3,1,2]],"regions":[` +
+				`{"label":1,"value":"1","size":3,"top":1,"left":1,"bottom":2,"right":2},` +
+				`{"label":2,"value":"2","size":2,"top":1,"left":3,"bottom":2,"right":3},` +
+				`{"label":3,"value":"3","size":1,"top":2,"left":1,"bottom":2,"right":1}]}` + "\n",
+			200,
+		},
+		{
+			"nonzero-4",
+			"mode=nonzero",
+			tiles,
+			`{"labels":[[1,1,0,0],[0,0,2,2],[0,0,2,0],[0,0,2,2]],"regions":[` +
+				`{"label":1,"size":2,"top":1,"left":1,"bottom":1,"right":2},` +
+				`{"label":2,"size":5,"top":2,"left":3,"bottom":4,"right":4}]}` + "\n",
+			200,
+		},
+		{
+			"nonzero-8",
+			"mode=nonzero&connectivity=8",
+			tiles,
+			`{"labels":[[1,1,0,0],[0,0,1,1],[0,0,1,0],[0,0,1,1]],"regions":[` +
+				`{"label":1,"size":7,"top":1,"left":1,"bottom":4,"right":4}]}` + "\n",
+			200,
+		},
+		{
+			"equal-8-zeros",
+			"connectivity=8",
+			[]byte("0,1\n1,0"),
+			`{"labels":[[1,2],[2,1]],"regions":[` +
+				`{"label":1,"value":"0","size":2,"top":1,"left":1,"bottom":2,"right":2},` +
+				`{"label":2,"value":"1","size":2,"top":1,"left":1,"bottom":2,"right":2}]}` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			`{"labels":[],"regions":[]}` + "\n",
+			200,
+		},
+		{
+			"invalid-connectivity",
+			"connectivity=6",
+			tiles,
+			"Error: connectivity must be one of 4 or 8\n",
+			400,
+		},
+		{
+			"invalid-mode",
+			"mode=positive",
+			tiles,
+			"Error: mode must be one of equal or nonzero\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleRegions)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleFloodFill(t *testing.T) {
+	grid := []byte("1,1,0\n0,1,0\n1,0,1")
+
+	tests := []queryTestCase{
+		{
+			"fill-4",
+			"row=1&col=1&value=7",
+			grid,
+			"7,7,0\n0,7,0\n1,0,1\n",
+			200,
+		},
+		{
+			"fill-8",
+			"row=1&col=1&value=7&connectivity=8",
+			grid,
+			"7,7,0\n0,7,0\n7,0,7\n",
+			200,
+		},
+		{
+			"fill-background",
+			"row=2&col=3&value=-2",
+			grid,
+			"1,1,-2\n0,1,-2\n1,0,1\n",
+			200,
+		},
+		{
+			"same-value",
+			"row=1&col=1&value=1",
+			grid,
+			"1,1,0\n0,1,0\n1,0,1\n",
+			200,
+		},
+		{
+			"rectangular",
+			"row=1&col=4&value=5",
+			[]byte("0,0,1,0\n1,0,0,0"),
+			"5,5,1,5\n1,5,5,5\n",
+			200,
+		},
+		{
+			"row-out-of-range",
+			"row=4&col=1&value=7",
+			grid,
+			"Error: row must be an integer between 1 and 3\n",
+			400,
+		},
+		{
+			"missing-col",
+			"row=1&value=7",
+			grid,
+			"Error: col must be an integer between 1 and 3\n",
+			400,
+		},
+		{
+			"invalid-value",
+			"row=1&col=1&value=x",
+			grid,
+			"Error: value must be an integer\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleFloodFill)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
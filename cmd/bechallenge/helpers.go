@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
+	h "net/http"
+	"strconv"
 	"strings"
 )
 
@@ -28,16 +33,219 @@ func atoi(in []string) ([]*big.Int, error) {
 // Converts the slice of big.Int's `in` to a string of concatenated
 // int literals.
 func itos(in []*big.Int) string {
-	var out string
+	var sb strings.Builder
+	writeInts(&sb, in)
 
-	for _, d := range in {
-		out += d.String() + ","
+	return sb.String()
+}
+
+// Writes the slice of big.Int's `in` to `sb` as comma separated int
+// literals.
+func writeInts(sb *strings.Builder, in []*big.Int) {
+	for i, d := range in {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(d.String())
+	}
+}
+
+// Converts the entries of the integer vector `v` to int literals, for
+// JSON responses where numbers would lose precision.
+func ivstrings(v []*big.Int) []string {
+	out := make([]string, len(v))
+
+	for i, d := range v {
+		out[i] = d.String()
 	}
 
-	if len(out) > 0 {
-		// Get rid of the last comma.
-		out = out[:len(out)-1]
+	return out
+}
+
+// Like ivstrings, for the integer matrix `m`.
+func imstrings(m [][]*big.Int) [][]string {
+	out := make([][]string, len(m))
+
+	for i, row := range m {
+		out[i] = ivstrings(row)
 	}
 
 	return out
 }
+
+// Converts the CSV records `recs` to a matrix of big.Int's. The error
+// identifies the offending record.
+func atom(recs [][]string) ([][]*big.Int, error) {
+	m := make([][]*big.Int, len(recs))
+
+	for ri, row := range recs {
+		ints, err := atoi(row)
+		if err != nil {
+			return nil, fmt.Errorf("record on line %d: %v", ri+1, err)
+		}
+
+		m[ri] = ints
+	}
+
+	return m, nil
+}
+
+// Converts the matrix `m` to a string of CSV rows in the format used
+// by the echo handler, including the trailing new line.
+func mtos(m [][]*big.Int) string {
+	var sb strings.Builder
+
+	for _, row := range m {
+		writeInts(&sb, row)
+		sb.WriteByte('\n')
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if len(m) == 0 {
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// Gets the CSV records from the request context and converts them to
+// a matrix of big.Int's. Reports parsing errors to the user; the
+// caller should return if `ok` is false.
+func parseMatrix(w h.ResponseWriter, r *h.Request) (m [][]*big.Int, ok bool) {
+	recs := r.Context().Value(csvRecordsKey).([][]string)
+
+	m, err := atom(recs)
+	if err != nil {
+		h.Error(w, "Error: parsing CSV: "+err.Error(), h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	return m, true
+}
+
+// Like parseMatrix, for both matrices uploaded to the handlers behind
+// binaryApiMiddleware.
+func parseMatrices(w h.ResponseWriter, r *h.Request) (a, b [][]*big.Int, ok bool) {
+	if a, ok = parseMatrix(w, r); !ok {
+		return nil, nil, false
+	}
+
+	recs := r.Context().Value(csvRecords2Key).([][]string)
+
+	b, err := atom(recs)
+	if err != nil {
+		h.Error(w, "Error: parsing CSV (file2): "+err.Error(), h.StatusBadRequest)
+
+		return nil, nil, false
+	}
+
+	return a, b, true
+}
+
+// Writes a matrix with `rows` rows in the format used by the echo
+// handler, one row at a time as returned by `row`. Unlike mtos, never
+// holds more than one row of the output in memory, and writes the
+// entries out one at a time.
+func streamMatrix(w h.ResponseWriter, rows int, row func(i int) []*big.Int) {
+	bw := bufio.NewWriter(w)
+
+	for i := 0; i < rows; i++ {
+		for j, d := range row(i) {
+			if j > 0 {
+				bw.WriteByte(',')
+			}
+			bw.WriteString(d.String())
+		}
+
+		// The bufio.Writer keeps the first error; checking it once per
+		// row is enough.
+		if err := bw.WriteByte('\n'); err != nil {
+			l.Error("writing response", "err", err)
+
+			return
+		}
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if rows == 0 {
+		bw.WriteString("\n")
+	}
+
+	if err := bw.Flush(); err != nil {
+		l.Error("writing response", "err", err)
+	}
+}
+
+// Sets z to the greatest common divisor of x and y and returns z. The
+// result is always non-negative; gcd(0, 0) is 0.
+func gcd(z, x, y *big.Int) *big.Int {
+	return z.GCD(nil, nil, x, y)
+}
+
+// Sets z to the least common multiple of x and y and returns z. The
+// result is always non-negative; lcm(x, 0) is 0.
+func lcm(z, x, y *big.Int) *big.Int {
+	if x.Sign() == 0 || y.Sign() == 0 {
+		return z.SetInt64(0)
+	}
+
+	g := gcd(new(big.Int), x, y)
+	p := new(big.Int).Mul(x, y)
+
+	return z.Abs(p.Quo(p, g))
+}
+
+// Writes `v` as a JSON response. Encoding errors only go in the logs as
+// the response headers have already been sent.
+func writeJSON(w h.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		l.Error("writing JSON response", "err", err)
+	}
+}
+
+// Gets the precision (in significant decimal digits) of the float
+// results from the `prec` query parameter, defaulting to `defaultPrec`.
+func parsePrec(r *h.Request) (int, error) {
+	s := r.FormValue("prec")
+	if s == "" {
+		return defaultPrec, nil
+	}
+
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 1 || p > maxPrec {
+		return 0, fmt.Errorf("prec must be an integer between 1 and %d", maxPrec)
+	}
+
+	return p, nil
+}
+
+// Like parsePrec, but defaults to 0, meaning exact (rational) results.
+func parseOptPrec(r *h.Request) (int, error) {
+	if r.FormValue("prec") == "" {
+		return 0, nil
+	}
+
+	return parsePrec(r)
+}
+
+// Returns the number of mantissa bits for big.Float's holding `prec`
+// significant decimal digits, with a few guard bits on top.
+func precBits(prec int) uint {
+	return uint(math.Ceil(float64(prec)*math.Log2(10))) + 16
+}
+
+// Formats the square root of the non-negative rational `x` with `prec`
+// significant decimal digits.
+func sqrtRat(x *big.Rat, prec int) string {
+	f := new(big.Float).SetPrec(precBits(prec)).SetRat(x)
+
+	return ftos(f.Sqrt(f), prec)
+}
+
+// Formats `f` with `prec` significant decimal digits.
+func ftos(f *big.Float, prec int) string {
+	return f.Text('g', prec)
+}
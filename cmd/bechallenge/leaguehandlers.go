@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The league handlers treat the square matrix as a results matrix: the
+// (i, j) entry is the score of team i in its match against team j.
+// The diagonal is ignored.
+
+// A row of the league table. The goal counts are strings so that JSON
+// clients don't lose precision.
+type standing struct {
+	Rank    int    `json:"rank"`
+	Team    string `json:"team"`
+	Played  int    `json:"played"`
+	Won     int    `json:"won"`
+	Drawn   int    `json:"drawn"`
+	Lost    int    `json:"lost"`
+	For     string `json:"for"`
+	Against string `json:"against"`
+	Diff    string `json:"diff"`
+	Points  int    `json:"points"`
+
+	// The index of the team in the results matrix, and the goal counts
+	// the tie-breakers compare.
+	team       int
+	gf, ga, gd *big.Int
+}
+
+// Tie-breakers supported by the standings API.
+var tieBreakers = map[string]bool{"gd": true, "h2h": true, "gf": true}
+
+// Handles standings requests by validating the supplied results matrix
+// of int literals and returning the league table. Expects the matrix
+// CSV in the request context.
+//
+// Query parameters:
+//   - win, draw, loss: points awarded for each result (3, 1 and 0 by
+//     default)
+//   - tiebreakers: comma separated criteria that order the teams level
+//     on points, applied in turn: gd (goal difference), h2h (points in
+//     the matches between the tied teams) and gf (goals scored). The
+//     default is gd,h2h,gf.
+//   - header: if true, the first row of the CSV holds the team labels
+//   - format: csv (default) or json
+//
+// Teams that are still level after all the tie-breakers share a rank.
+func handleStandings(w h.ResponseWriter, r *h.Request) {
+	points := map[string]int{"win": 3, "draw": 1, "loss": 0}
+	for k := range points {
+		if s := r.FormValue(k); s != "" {
+			p, err := strconv.Atoi(s)
+			if err != nil {
+				h.Error(w, fmt.Sprintf("Error: %s must be an integer", k), h.StatusBadRequest)
+
+				return
+			}
+			points[k] = p
+		}
+	}
+
+	criteria := []string{"gd", "h2h", "gf"}
+	if s := r.FormValue("tiebreakers"); s != "" {
+		criteria = strings.Split(s, ",")
+		for _, c := range criteria {
+			if !tieBreakers[c] {
+				h.Error(w, fmt.Sprintf("Error: unknown tie-breaker %q", c), h.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	format := r.FormValue("format")
+	if format != "" && format != "csv" && format != "json" {
+		h.Error(w, fmt.Sprintf("Error: unknown format %q", format), h.StatusBadRequest)
+
+		return
+	}
+
+	labels, m, ok := parseLabeledMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	table := standings(m, labels, points, criteria)
+
+	if format == "json" {
+		writeJSON(w, table)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"rank", "team", "played", "won", "drawn", "lost", "for", "against", "diff", "points",
+	})
+	for _, s := range table {
+		cw.Write([]string{
+			fmt.Sprint(s.Rank), s.Team, fmt.Sprint(s.Played), fmt.Sprint(s.Won),
+			fmt.Sprint(s.Drawn), fmt.Sprint(s.Lost), s.For, s.Against, s.Diff,
+			fmt.Sprint(s.Points),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		l.Error("writing CSV response", "err", err)
+	}
+}
+
+// Gets the square matrix from the request context, taking the team
+// labels from its first row if the `header` query parameter is true,
+// or numbering the teams from 1 otherwise. Reports errors to the user;
+// the caller should return if `ok` is false.
+func parseLabeledMatrix(w h.ResponseWriter, r *h.Request) (labels []string, m [][]*big.Int, ok bool) {
+	recs := r.Context().Value(csvRecordsKey).([][]string)
+
+	if r.FormValue("header") == "true" && len(recs) > 0 {
+		for _, s := range recs[0] {
+			labels = append(labels, strings.TrimSpace(s))
+		}
+		recs = recs[1:]
+	}
+
+	// The CSV reader already checks that the records (including the
+	// header) have the same length.
+	if labels != nil && len(labels) != len(recs) || len(recs) > 0 && len(recs) != len(recs[0]) {
+		h.Error(w, "Error: matrix is not square", h.StatusBadRequest)
+
+		return nil, nil, false
+	}
+
+	m, err := atom(recs)
+	if err != nil {
+		h.Error(w, "Error: parsing CSV: "+err.Error(), h.StatusBadRequest)
+
+		return nil, nil, false
+	}
+
+	if labels == nil {
+		for i := range m {
+			labels = append(labels, fmt.Sprint(i+1))
+		}
+	}
+
+	return labels, m, true
+}
+
+// Computes the league table from the results matrix `m` (see
+// handleStandings).
+func standings(m [][]*big.Int, labels []string, points map[string]int, criteria []string) []standing {
+	n := len(m)
+
+	table := make([]standing, n)
+	for i := range table {
+		table[i] = standing{
+			Team: labels[i], team: i, gf: new(big.Int), ga: new(big.Int), gd: new(big.Int),
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+
+			s := &table[i]
+			s.Played++
+			s.gf.Add(s.gf, m[i][j])
+			s.ga.Add(s.ga, m[j][i])
+
+			switch m[i][j].Cmp(m[j][i]) {
+			case 1:
+				s.Won++
+				s.Points += points["win"]
+			case 0:
+				s.Drawn++
+				s.Points += points["draw"]
+			case -1:
+				s.Lost++
+				s.Points += points["loss"]
+			}
+		}
+	}
+	for i := range table {
+		table[i].gd.Sub(table[i].gf, table[i].ga)
+	}
+
+	// Order by points, then break the ties within each group of level
+	// teams.
+	teams := make([]int, n)
+	for i := range teams {
+		teams[i] = i
+	}
+	key := func(i int) *big.Int { return big.NewInt(int64(table[i].Points)) }
+
+	ranked := []standing{}
+	for _, level := range splitTies(teams, key) {
+		for _, tied := range breakTies(level, table, m, points, criteria) {
+			rank := len(ranked) + 1
+			for _, i := range tied {
+				s := table[i]
+				s.Rank = rank
+				s.For, s.Against, s.Diff = s.gf.String(), s.ga.String(), s.gd.String()
+				ranked = append(ranked, s)
+			}
+		}
+	}
+
+	return ranked
+}
+
+// Orders the teams level on points in `tied` with the first of the
+// `criteria` and recurses into the groups still level with the rest.
+// Returns the groups of teams in order; teams in the same group share a
+// rank.
+func breakTies(tied []int, table []standing, m [][]*big.Int, points map[string]int, criteria []string) [][]int {
+	if len(tied) == 1 || len(criteria) == 0 {
+		return [][]int{tied}
+	}
+
+	var key func(i int) *big.Int
+	switch criteria[0] {
+	case "gd":
+		key = func(i int) *big.Int { return table[i].gd }
+	case "gf":
+		key = func(i int) *big.Int { return table[i].gf }
+	case "h2h":
+		// The points won in the matches between the tied teams only.
+		h2h := make(map[int]int64)
+		for _, i := range tied {
+			for _, j := range tied {
+				if i == j {
+					continue
+				}
+
+				switch m[i][j].Cmp(m[j][i]) {
+				case 1:
+					h2h[i] += int64(points["win"])
+				case 0:
+					h2h[i] += int64(points["draw"])
+				case -1:
+					h2h[i] += int64(points["loss"])
+				}
+			}
+		}
+		key = func(i int) *big.Int { return big.NewInt(h2h[i]) }
+	}
+
+	var groups [][]int
+	for _, g := range splitTies(tied, key) {
+		groups = append(groups, breakTies(g, table, m, points, criteria[1:])...)
+	}
+
+	return groups
+}
+
+// Sorts the teams by descending `key`, keeping the matrix order among
+// equal keys, and splits them into groups of equal keys.
+func splitTies(teams []int, key func(i int) *big.Int) [][]int {
+	sorted := slices.Clone(teams)
+	slices.SortStableFunc(sorted, func(i, j int) int {
+		return key(j).Cmp(key(i))
+	})
+
+	var groups [][]int
+	for k, i := range sorted {
+		if k > 0 && key(i).Cmp(key(sorted[k-1])) == 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], i)
+		} else {
+			groups = append(groups, []int{i})
+		}
+	}
+
+	return groups
+}
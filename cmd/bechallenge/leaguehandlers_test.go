@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHandleStandings(t *testing.T) {
+	// Everybody ends on 4 points: A beats B 2-1, draws with C 1-1 and
+	// loses to D 0-3, B beats C 3-0 and draws with D 2-2, C beats D 2-0.
+	results := []byte("0,2,1,0\n1,0,3,2\n1,0,0,2\n3,2,0,0")
+
+	tests := []queryTestCase{
+		{
+			"goal-difference",
+			"",
+			results,
+			"rank,team,played,won,drawn,lost,for,against,diff,points\n" +
+				"1,2,3,1,1,1,6,4,2,4\n" +
+				"2,4,3,1,1,1,5,4,1,4\n" +
+				"3,3,3,1,1,1,3,4,-1,4\n" +
+				"4,1,3,1,1,1,3,5,-2,4\n",
+			200,
+		},
+		{
+			"goals-scored-shared-rank",
+			"tiebreakers=gf",
+			results,
+			"rank,team,played,won,drawn,lost,for,against,diff,points\n" +
+				"1,2,3,1,1,1,6,4,2,4\n" +
+				"2,4,3,1,1,1,5,4,1,4\n" +
+				"3,1,3,1,1,1,3,5,-2,4\n" +
+				"3,3,3,1,1,1,3,4,-1,4\n",
+			200,
+		},
+		{
+			"head-to-head",
+			"tiebreakers=h2h,gd&header=true",
+			// A and B both win 6 points, B by more goals, but A beat B;
+			// D beat C.
+			[]byte("A,B,C,D\n0,1,0,1\n0,0,5,5\n1,0,0,0\n0,0,1,0"),
+			"rank,team,played,won,drawn,lost,for,against,diff,points\n" +
+				"1,A,3,2,0,1,2,1,1,6\n" +
+				"2,B,3,2,0,1,10,1,9,6\n" +
+				"3,D,3,1,0,2,1,6,-5,3\n" +
+				"4,C,3,1,0,2,1,6,-5,3\n",
+			200,
+		},
+		{
+			"custom-points",
+			"win=2&draw=1&loss=-1&header=true",
+			[]byte("Ajax,PSV\n2,1\n1,2"),
+			"rank,team,played,won,drawn,lost,for,against,diff,points\n" +
+				"1,Ajax,1,0,1,0,1,1,0,1\n" +
+				"1,PSV,1,0,1,0,1,1,0,1\n",
+			200,
+		},
+		{
+			"json",
+			"format=json&header=true",
+			[]byte("A,B\n0,3\n1,0"),
+			`[{"rank":1,"team":"A","played":1,"won":1,"drawn":0,"lost":0,"for":"3","against":"1","diff":"2","points":3},` +
+				`{"rank":2,"team":"B","played":1,"won":0,"drawn":0,"lost":1,"for":"1","against":"3","diff":"-2","points":0}]` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"format=json",
+			[]byte{},
+			"[]\n",
+			200,
+		},
+		{
+			"header-not-square",
+			"header=true",
+			[]byte("A,B\n0,3\n1,0\n2,2"),
+			"Error: matrix is not square\n",
+			400,
+		},
+		{
+			"not-square",
+			"",
+			[]byte("0,3\n1,0\n2,2"),
+			"Error: matrix is not square\n",
+			400,
+		},
+		{
+			"unknown-tiebreaker",
+			"tiebreakers=gd,coin",
+			results,
+			"Error: unknown tie-breaker \"coin\"\n",
+			400,
+		},
+		{
+			"invalid-points",
+			"win=three",
+			results,
+			"Error: win must be an integer\n",
+			400,
+		},
+		{
+			"unknown-format",
+			"format=xml",
+			results,
+			"Error: unknown format \"xml\"\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			"",
+			[]byte("0,1.5\n1,0"),
+			"Error: parsing CSV: record on line 1: parsing \"1.5\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleStandings)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
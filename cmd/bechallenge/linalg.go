@@ -0,0 +1,400 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var errSingular = errors.New("matrix is singular")
+
+// Converts the integer matrix `m` to a rational one.
+func ratMatrix(m [][]*big.Int) [][]*big.Rat {
+	out := make([][]*big.Rat, len(m))
+
+	for i, row := range m {
+		out[i] = make([]*big.Rat, len(row))
+		for j, d := range row {
+			out[i][j] = new(big.Rat).SetInt(d)
+		}
+	}
+
+	return out
+}
+
+// Returns a zero filled rows x cols rational matrix.
+func newRatMatrix(rows, cols int) [][]*big.Rat {
+	out := make([][]*big.Rat, rows)
+
+	for i := range out {
+		out[i] = make([]*big.Rat, cols)
+		for j := range out[i] {
+			out[i][j] = new(big.Rat)
+		}
+	}
+
+	return out
+}
+
+// Returns the n x n rational identity matrix.
+func identityRat(n int) [][]*big.Rat {
+	out := newRatMatrix(n, n)
+
+	for i := range out {
+		out[i][i].SetInt64(1)
+	}
+
+	return out
+}
+
+// Returns a deep copy of the rational matrix `a`.
+func cloneRat(a [][]*big.Rat) [][]*big.Rat {
+	out := make([][]*big.Rat, len(a))
+
+	for i, row := range a {
+		out[i] = make([]*big.Rat, len(row))
+		for j, x := range row {
+			out[i][j] = new(big.Rat).Set(x)
+		}
+	}
+
+	return out
+}
+
+// Returns the number of columns of the matrix `a`.
+func ncols[T any](a [][]T) int {
+	if len(a) == 0 {
+		return 0
+	}
+
+	return len(a[0])
+}
+
+// Returns the transpose of the rational matrix `a`.
+func transposeRat(a [][]*big.Rat) [][]*big.Rat {
+	out := make([][]*big.Rat, ncols(a))
+
+	for i := range out {
+		out[i] = make([]*big.Rat, len(a))
+		for j := range a {
+			out[i][j] = new(big.Rat).Set(a[j][i])
+		}
+	}
+
+	return out
+}
+
+// Returns the product of the rational matrices `a` and `b`. The number
+// of columns of `a` must match the number of rows of `b`.
+func mulRat(a, b [][]*big.Rat) [][]*big.Rat {
+	out := newRatMatrix(len(a), ncols(b))
+	t := new(big.Rat)
+
+	for i := range a {
+		for j := range out[i] {
+			for k := range b {
+				out[i][j].Add(out[i][j], t.Mul(a[i][k], b[k][j]))
+			}
+		}
+	}
+
+	return out
+}
+
+// Returns the n-th power of the square rational matrix `a`, computed
+// by repeated squaring.
+func powRat(a [][]*big.Rat, n int) [][]*big.Rat {
+	return powMatrix(a, big.NewInt(int64(n)), identityRat(len(a)), mulRat)
+}
+
+// Returns the n-th power of the square matrix `a` with left to right
+// binary exponentiation, given the identity matrix `one` of its size
+// and the matrix product `mul`, which must return a new matrix.
+func powMatrix[T any](a [][]T, n *big.Int, one [][]T, mul func(x, y [][]T) [][]T) [][]T {
+	out := one
+	for i := n.BitLen() - 1; i >= 0; i-- {
+		out = mul(out, out)
+		if n.Bit(i) == 1 {
+			out = mul(out, a)
+		}
+	}
+
+	return out
+}
+
+// Brings the rational matrix `a` to reduced row echelon form in place
+// with Gauss-Jordan elimination. Returns the pivot column of each of
+// the non-zero rows; their number is the rank of `a`.
+func rref(a [][]*big.Rat) []int {
+	var pivots []int
+	t := new(big.Rat)
+
+	for c, r := 0, 0; c < ncols(a) && r < len(a); c++ {
+		// Find a row with a non-zero entry in the column.
+		p := r
+		for p < len(a) && a[p][c].Sign() == 0 {
+			p++
+		}
+		if p == len(a) {
+			continue
+		}
+		a[r], a[p] = a[p], a[r]
+
+		// Scale the pivot to 1.
+		inv := new(big.Rat).Inv(a[r][c])
+		for j := c; j < len(a[r]); j++ {
+			a[r][j].Mul(a[r][j], inv)
+		}
+
+		// Eliminate the column from all other rows.
+		for i := range a {
+			if i == r || a[i][c].Sign() == 0 {
+				continue
+			}
+
+			f := new(big.Rat).Set(a[i][c])
+			for j := c; j < len(a[i]); j++ {
+				a[i][j].Sub(a[i][j], t.Mul(f, a[r][j]))
+			}
+		}
+
+		pivots = append(pivots, c)
+		r++
+	}
+
+	return pivots
+}
+
+// Solves the linear system a·x = b exactly, where `a` is square and `b`
+// has one column per right hand side. Doesn't modify its arguments.
+func solveRat(a, b [][]*big.Rat) ([][]*big.Rat, error) {
+	n := len(a)
+
+	// Reduce the augmented matrix [a|b].
+	aug, rhs := cloneRat(a), cloneRat(b)
+	for i := range aug {
+		aug[i] = append(aug[i], rhs[i]...)
+	}
+
+	// The system has a unique solution iff every column of `a` has
+	// a pivot.
+	if pivots := rref(aug); n > 0 && (len(pivots) < n || pivots[n-1] != n-1) {
+		return nil, errSingular
+	}
+
+	x := make([][]*big.Rat, n)
+	for i := range aug {
+		x[i] = aug[i][n:]
+	}
+
+	return x, nil
+}
+
+// Returns the inverse of the square rational matrix `a`.
+func inverseRat(a [][]*big.Rat) ([][]*big.Rat, error) {
+	return solveRat(a, identityRat(len(a)))
+}
+
+// Formats the rational `x` as an exact fraction in lowest terms if
+// `prec` is 0, or as a decimal with `prec` significant digits.
+func rtos(x *big.Rat, prec int) string {
+	if prec == 0 {
+		return x.RatString()
+	}
+
+	return ftos(new(big.Float).SetPrec(precBits(prec)).SetRat(x), prec)
+}
+
+// Converts the rational matrix `a` to a string of CSV rows (see mtos)
+// formatting the entries with rtos.
+func rmtos(a [][]*big.Rat, prec int) string {
+	var sb strings.Builder
+
+	for _, row := range a {
+		sb.WriteString(strings.Join(rvstrings(row, prec), ","))
+		sb.WriteByte('\n')
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if len(a) == 0 {
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// Formats the entries of the rational vector `v` with rtos.
+func rvstrings(v []*big.Rat, prec int) []string {
+	out := make([]string, len(v))
+
+	for i, x := range v {
+		out[i] = rtos(x, prec)
+	}
+
+	return out
+}
+
+// Formats the entries of the rational matrix `a` with rtos.
+func rmstrings(a [][]*big.Rat, prec int) [][]string {
+	out := make([][]string, len(a))
+
+	for i, row := range a {
+		out[i] = rvstrings(row, prec)
+	}
+
+	return out
+}
+
+// Returns the product of the integer matrices `a` and `b`. The number
+// of columns of `a` must match the number of rows of `b`.
+func mulInt(a, b [][]*big.Int) [][]*big.Int {
+	out := make([][]*big.Int, len(a))
+	t := new(big.Int)
+
+	for i := range a {
+		out[i] = make([]*big.Int, ncols(b))
+		for j := range out[i] {
+			out[i][j] = new(big.Int)
+			for k := range b {
+				out[i][j].Add(out[i][j], t.Mul(a[i][k], b[k][j]))
+			}
+		}
+	}
+
+	return out
+}
+
+// Returns the n-th power of the square integer matrix `a`, computed by
+// repeated squaring. Reduces the entries modulo `mod` along the way,
+// unless it's nil.
+func powInt(a [][]*big.Int, n, mod *big.Int) [][]*big.Int {
+	one := make([][]*big.Int, len(a))
+	for i := range one {
+		one[i] = make([]*big.Int, len(a))
+		for j := range one[i] {
+			one[i][j] = new(big.Int)
+		}
+		one[i][i].SetInt64(1)
+	}
+
+	reduce := func(m [][]*big.Int) [][]*big.Int {
+		if mod != nil {
+			for _, row := range m {
+				for _, d := range row {
+					d.Mod(d, mod)
+				}
+			}
+		}
+
+		return m
+	}
+
+	return reduce(powMatrix(a, n, one, func(x, y [][]*big.Int) [][]*big.Int {
+		return reduce(mulInt(x, y))
+	}))
+}
+
+// Reports whether the integer matrices `a` and `b` are equal.
+func equalInt(a, b [][]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j].Cmp(b[i][j]) != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Reports whether all entries of the integer matrix `a` are zero.
+func isZeroInt(a [][]*big.Int) bool {
+	for _, row := range a {
+		for _, d := range row {
+			if d.Sign() != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns the determinant of the square integer matrix `m`. Uses the
+// fraction-free Bareiss algorithm, so all the intermediate values are
+// integers no larger than the minors of `m`.
+func detInt(m [][]*big.Int) *big.Int {
+	n := len(m)
+	if n == 0 {
+		return big.NewInt(1)
+	}
+
+	a := make([][]*big.Int, n)
+	for i, row := range m {
+		a[i] = make([]*big.Int, n)
+		for j, d := range row {
+			a[i][j] = new(big.Int).Set(d)
+		}
+	}
+
+	sign, prev := 1, big.NewInt(1)
+	t := new(big.Int)
+
+	for k := 0; k < n-1; k++ {
+		// Find a non-zero pivot.
+		if a[k][k].Sign() == 0 {
+			p := k + 1
+			for p < n && a[p][k].Sign() == 0 {
+				p++
+			}
+			if p == n {
+				return new(big.Int)
+			}
+
+			a[k], a[p] = a[p], a[k]
+			sign = -sign
+		}
+
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				// a[i][j] = (a[i][j]·a[k][k] - a[i][k]·a[k][j]) / prev
+				a[i][j].Mul(a[i][j], a[k][k])
+				a[i][j].Sub(a[i][j], t.Mul(a[i][k], a[k][j]))
+				a[i][j].Quo(a[i][j], prev)
+			}
+		}
+
+		prev = a[k][k]
+	}
+
+	det := new(big.Int).Set(a[n-1][n-1])
+	if sign < 0 {
+		det.Neg(det)
+	}
+
+	return det
+}
+
+// Returns the square integer matrix `m` without row `i` and column `j`.
+func submatrix(m [][]*big.Int, i, j int) [][]*big.Int {
+	out := make([][]*big.Int, 0, len(m)-1)
+
+	for ri, row := range m {
+		if ri == i {
+			continue
+		}
+
+		r := make([]*big.Int, 0, len(row)-1)
+		r = append(r, row[:j]...)
+		out = append(out, append(r, row[j+1:]...))
+	}
+
+	return out
+}
@@ -11,8 +11,13 @@ type contextKey string
 
 const (
 	// in bytes
-	maxUploadSize            = 10 * 1024 * 1024
-	csvRecordsKey contextKey = "csvrecords"
+	maxUploadSize             = 10 * 1024 * 1024
+	csvRecordsKey  contextKey = "csvrecords"
+	csvRecords2Key contextKey = "csvrecords2"
+	// Default and max number of significant decimal digits in float
+	// results.
+	defaultPrec = 20
+	maxPrec     = 1000
 )
 
 var l *slog.Logger
@@ -27,14 +32,83 @@ func init() {
 }
 
 func main() {
-	mw := webApiMiddleware
+	emw := entrywiseApiMiddleware
 
 	// Web API (complete).
-	h.HandleFunc("/echo", mw(handleEcho))
-	h.HandleFunc("/invert", mw(handleInvert))
-	h.HandleFunc("/flatten", mw(handleFlatten))
-	h.HandleFunc("/sum", mw(handleSum))
-	h.HandleFunc("/multiply", mw(handleMultiply))
+	h.HandleFunc("/echo", emw(handleEcho))
+	h.HandleFunc("/invert", emw(handleInvert))
+	h.HandleFunc("/flatten", emw(handleFlatten))
+	h.HandleFunc("/sum", emw(handleSum))
+	h.HandleFunc("/multiply", emw(handleMultiply))
+	h.HandleFunc("/min", emw(handleMin))
+	h.HandleFunc("/max", emw(handleMax))
+	h.HandleFunc("/gcd", emw(handleGcd))
+	h.HandleFunc("/lcm", emw(handleLcm))
+	h.HandleFunc("/count-nonzero", emw(handleCountNonzero))
+	h.HandleFunc("/mean", emw(handleMean))
+	h.HandleFunc("/map", emw(handleMap))
+	h.HandleFunc("/stats", emw(handleStats))
+
+	// Web API on square matrices.
+	mw := webApiMiddleware
+	h.HandleFunc("/permanent", mw(handlePermanent))
+	h.HandleFunc("/minor", mw(handleMinor))
+	h.HandleFunc("/cofactors", mw(handleCofactors))
+	h.HandleFunc("/adjugate", mw(handleAdjugate))
+	h.HandleFunc("/cond", mw(handleCond))
+	h.HandleFunc("/graph/shortest-paths", mw(handleShortestPaths))
+	h.HandleFunc("/graph/components", mw(handleComponents))
+	h.HandleFunc("/graph/transitive-closure", mw(handleTransitiveClosure))
+	h.HandleFunc("/graph/toposort", mw(handleToposort))
+	h.HandleFunc("/graph/degree", mw(handleDegree))
+	h.HandleFunc("/graph/maxflow", mw(handleMaxFlow))
+	h.HandleFunc("/graph/mst", mw(handleMST))
+	h.HandleFunc("/assign", mw(handleAssign))
+	h.HandleFunc("/markov", mw(handleMarkov))
+	h.HandleFunc("/cipher/hill", mw(handleHill))
+	h.HandleFunc("/puzzle/validate", mw(handlePuzzleValidate))
+	h.HandleFunc("/puzzle/solve", mw(handlePuzzleSolve))
+
+	// Web API accepting non-square matrices.
+	rmw := rectApiMiddleware
+	h.HandleFunc("/covariance", rmw(handleCovariance))
+	h.HandleFunc("/correlation", rmw(handleCorrelation))
+	h.HandleFunc("/regress", rmw(handleRegress))
+	h.HandleFunc("/transpose", rmw(handleInvert))
+	h.HandleFunc("/properties", rmw(handleProperties))
+	h.HandleFunc("/norm", rmw(handleNorm))
+	h.HandleFunc("/pinv", rmw(handlePinv))
+	h.HandleFunc("/svd", rmw(handleSVD))
+	h.HandleFunc("/convolve", rmw(handleConvolve))
+	h.HandleFunc("/rotate", rmw(handleRotate))
+	h.HandleFunc("/flip", rmw(handleFlip))
+	h.HandleFunc("/antitranspose", rmw(handleAntitranspose))
+	h.HandleFunc("/spiral", rmw(handleSpiral))
+	h.HandleFunc("/zigzag", rmw(handleZigzag))
+	h.HandleFunc("/diagonals", rmw(handleDiagonals))
+	h.HandleFunc("/automaton", rmw(handleAutomaton))
+	h.HandleFunc("/grid/regions", rmw(handleRegions))
+	h.HandleFunc("/grid/floodfill", rmw(handleFloodFill))
+
+	// Web API on matrices that may carry a header row of node or team
+	// labels, so the handlers check the shape themselves. The schedule
+	// handler takes a number of teams or a CSV of labels.
+	qmw := queryApiMiddleware
+	h.HandleFunc("/graph/pagerank", rmw(handlePageRank))
+	h.HandleFunc("/graph/centrality", rmw(handleCentrality))
+	h.HandleFunc("/league/standings", rmw(handleStandings))
+	h.HandleFunc("/league/ratings", rmw(handleRatings))
+	h.HandleFunc("/league/schedule", qmw(handleSchedule))
+
+	// Web API operating on two matrices.
+	bmw := binaryApiMiddleware
+	h.HandleFunc("/kronecker", bmw(handleKronecker))
+	h.HandleFunc("/hadamard", bmw(handleHadamard))
+	h.HandleFunc("/directsum", bmw(handleDirectSum))
+
+	// Web API computing from the query parameters alone.
+	h.HandleFunc("/recurrence", qmw(handleRecurrence))
+	h.HandleFunc("/puzzle/generate", qmw(handlePuzzleGenerate))
 
 	// Stream API (example).
 	h.HandleFunc("/stream/echo", handleEchoStream)
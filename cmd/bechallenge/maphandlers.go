@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"strings"
+)
+
+const (
+	// Caps the exponent accepted by the pow operation.
+	maxPowExponent = 4096
+
+	// Caps the size of the entries, in bits, after each operation of a
+	// chain, so that a request can't make us build astronomically large
+	// integers by repeating pow or square.
+	maxMapBits = 1 << 18
+
+	// Caps the bits of the entries summed over all the entries and all
+	// the operations of a chain, which bounds both the memory the
+	// result takes and the work of computing it.
+	maxMapTotalBits = 1 << 26
+)
+
+// An elementwise operation. Sets z to the result of applying the
+// operation to x with the (optional) argument arg.
+type mapFunc func(z, x, arg *big.Int) error
+
+// A parsed step of an elementwise operation chain.
+type mapStep struct {
+	fn  mapFunc
+	arg *big.Int
+}
+
+// Elementwise operations supported by the map API. The flag tells
+// whether the operation requires an argument.
+var mapOps = map[string]struct {
+	needsArg bool
+	fn       mapFunc
+}{
+	"abs": {false, func(z, x, _ *big.Int) error {
+		z.Abs(x)
+		return nil
+	}},
+	"neg": {false, func(z, x, _ *big.Int) error {
+		z.Neg(x)
+		return nil
+	}},
+	"sign": {false, func(z, x, _ *big.Int) error {
+		z.SetInt64(int64(x.Sign()))
+		return nil
+	}},
+	"square": {false, func(z, x, _ *big.Int) error {
+		z.Mul(x, x)
+		return nil
+	}},
+	"add": {true, func(z, x, arg *big.Int) error {
+		z.Add(x, arg)
+		return nil
+	}},
+	"mul": {true, func(z, x, arg *big.Int) error {
+		z.Mul(x, arg)
+		return nil
+	}},
+	"mod": {true, func(z, x, arg *big.Int) error {
+		if arg.Sign() == 0 {
+			return errors.New("mod: division by zero")
+		}
+		// Euclidean modulus, always non-negative.
+		z.Mod(x, arg)
+		return nil
+	}},
+	"pow": {true, func(z, x, arg *big.Int) error {
+		if arg.Sign() < 0 || arg.Cmp(big.NewInt(maxPowExponent)) > 0 {
+			return fmt.Errorf("pow: exponent must be between 0 and %d", maxPowExponent)
+		}
+		if int64(x.BitLen()-1)*arg.Int64() > maxMapBits {
+			return fmt.Errorf("pow: result exceeds %d bits", maxMapBits)
+		}
+		z.Exp(x, arg, nil)
+		return nil
+	}},
+	"gcd": {true, func(z, x, arg *big.Int) error {
+		gcd(z, x, arg)
+		return nil
+	}},
+	"lcm": {true, func(z, x, arg *big.Int) error {
+		lcm(z, x, arg)
+		return nil
+	}},
+}
+
+// Handles map requests by applying a chain of elementwise operations
+// to the supplied matrix of int literals and returning the result in
+// the same shape. Each entry is limited to maxMapBits bits after every
+// operation, and their sizes summed over the whole chain to
+// maxMapTotalBits. Expects the matrix CSV in the request context.
+//
+// The chain is given as a comma separated list of operations with
+// optional colon separated arguments:
+//
+//	/map?op=abs,mod:7
+//
+// The `arg` query parameter supplies the argument for an operation
+// that needs one and doesn't have its own:
+//
+//	/map?op=mul&arg=3
+func handleMap(w h.ResponseWriter, r *h.Request) {
+	steps, err := parseMapSteps(r.FormValue("op"), r.FormValue("arg"))
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	total := 0
+	for _, row := range m {
+		for _, d := range row {
+			for _, s := range steps {
+				if err := s.fn(d, d, s.arg); err != nil {
+					h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+					return
+				}
+				if d.BitLen() > maxMapBits {
+					h.Error(w, fmt.Sprintf("Error: result exceeds %d bits", maxMapBits), h.StatusBadRequest)
+
+					return
+				}
+
+				if total += d.BitLen(); total > maxMapTotalBits {
+					h.Error(w, fmt.Sprintf("Error: results exceed %d bits in total", maxMapTotalBits),
+						h.StatusBadRequest)
+
+					return
+				}
+			}
+		}
+	}
+
+	fmt.Fprint(w, mtos(m))
+}
+
+// Parses the operation chain `ops`. The default argument `arg` is used
+// for operations that need an argument but don't specify their own.
+func parseMapSteps(ops, arg string) ([]mapStep, error) {
+	if ops == "" {
+		return nil, errors.New("missing op parameter")
+	}
+
+	var steps []mapStep
+
+	for _, op := range strings.Split(ops, ",") {
+		name, a, hasArg := strings.Cut(strings.TrimSpace(op), ":")
+
+		o, ok := mapOps[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown op %q", name)
+		}
+
+		s := mapStep{fn: o.fn}
+
+		if !hasArg {
+			a = arg
+		}
+
+		if o.needsArg {
+			if a == "" {
+				return nil, fmt.Errorf("op %q requires an argument", name)
+			}
+
+			ints, err := atoi([]string{a})
+			if err != nil {
+				return nil, fmt.Errorf("op %q: %v", name, err)
+			}
+			s.arg = ints[0]
+		} else if hasArg {
+			return nil, fmt.Errorf("op %q doesn't take an argument", name)
+		}
+
+		steps = append(steps, s)
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleMap(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"mul-with-arg",
+			"op=mul&arg=3",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"3,6,9\n12,15,18\n21,24,27\n",
+			200,
+		},
+		{
+			"chained",
+			"op=abs,mod:7",
+			[]byte("-1,2,-3\n4,-5,6\n-7,8,-9"),
+			"1,2,3\n4,5,6\n0,1,2\n",
+			200,
+		},
+		{
+			"negative-mod-is-euclidean",
+			"op=mod:7",
+			[]byte("-1,-8\n-14,15"),
+			"6,6\n0,1\n",
+			200,
+		},
+		{
+			"neg-sign-square",
+			"op=neg,sign,square",
+			[]byte("-5,0\n3,-12345678901234567890"),
+			"1,0\n1,1\n",
+			200,
+		},
+		{
+			"add-pow",
+			"op=add:1,pow:2",
+			[]byte("1,2\n3,-1"),
+			"4,9\n16,0\n",
+			200,
+		},
+		{
+			"gcd-lcm",
+			"op=gcd:12,lcm:8",
+			[]byte("18,-4\n0,7"),
+			"24,8\n24,8\n",
+			200,
+		},
+		{
+			"large-integers",
+			"op=mul&arg=-12345678901234567890",
+			[]byte("12345678901234567890"),
+			"-152415787532388367501905199875019052100\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"op=abs",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"missing-op",
+			"",
+			[]byte("1"),
+			"Error: missing op parameter\n",
+			400,
+		},
+		{
+			"unknown-op",
+			"op=abs,sqrt",
+			[]byte("1"),
+			"Error: unknown op \"sqrt\"\n",
+			400,
+		},
+		{
+			"missing-arg",
+			"op=mul",
+			[]byte("1"),
+			"Error: op \"mul\" requires an argument\n",
+			400,
+		},
+		{
+			"unexpected-arg",
+			"op=abs:3",
+			[]byte("1"),
+			"Error: op \"abs\" doesn't take an argument\n",
+			400,
+		},
+		{
+			"invalid-arg",
+			"op=add:x",
+			[]byte("1"),
+			"Error: op \"add\": parsing \"x\": invalid syntax\n",
+			400,
+		},
+		{
+			"mod-by-zero",
+			"op=mod:0",
+			[]byte("1"),
+			"Error: mod: division by zero\n",
+			400,
+		},
+		{
+			"pow-exponent-too-large",
+			"op=pow:5000",
+			[]byte("1"),
+			"Error: pow: exponent must be between 0 and 4096\n",
+			400,
+		},
+		{
+			"chained-pow",
+			"op=pow:4096,pow:4096,pow:4096",
+			[]byte("2"),
+			"Error: pow: result exceeds 262144 bits\n",
+			400,
+		},
+		{
+			"chained-square",
+			"op=square,square,square,square,square,square,square,square,square,square,square,square,square,square,square,square,square,square,square,square",
+			[]byte("3"),
+			"Error: result exceeds 262144 bits\n",
+			400,
+		},
+		{
+			"total-bits",
+			"op=pow:4096",
+			[]byte(strings.Repeat(strings.Repeat("4611686018427387904,", 16)+"4611686018427387904\n", 17)),
+			"Error: results exceed 67108864 bits in total\n",
+			400,
+		},
+		{
+			"chained-pow-ones",
+			"op=pow:4096,pow:4096,neg,pow:4095",
+			[]byte("1,-1\n0,1"),
+			"-1,-1\n0,-1\n",
+			200,
+		},
+		{
+			"non-integer-literals",
+			"op=abs",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := entrywiseApiMiddleware(handleMap)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
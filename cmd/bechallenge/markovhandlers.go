@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+	"strconv"
+)
+
+const (
+	// Caps the number of steps of the n-step transition probabilities;
+	// the denominators grow linearly in size with it.
+	maxMarkovSteps = 10000
+
+	// Caps the size in bits of the denominators of the n-step transition
+	// probabilities, which divide the steps-th power of the least common
+	// multiple of the row sums.
+	maxMarkovStepBits = 1 << 12
+
+	// The most states of a Markov chain; the analysis takes O(n³)
+	// operations on fractions.
+	maxMarkovStates = 16
+)
+
+// The analysis of a Markov chain. States are numbered from 1. The
+// probabilities are exact fractions or decimals.
+type markovChain struct {
+	// The transition probabilities.
+	Transition [][]string `json:"transition"`
+	// The stationary distribution, or null if it isn't unique.
+	Stationary []string `json:"stationary"`
+	// The n-step transition probabilities, if asked for.
+	Steps *int       `json:"steps,omitempty"`
+	NStep [][]string `json:"nstep,omitempty"`
+	// The recurrent states, which are certain to be revisited, the
+	// absorbing ones among them, which can't be left, and the transient
+	// ones, which reach states that don't lead back to them.
+	Recurrent []int `json:"recurrent"`
+	Absorbing []int `json:"absorbing"`
+	Transient []int `json:"transient"`
+	// For each transient state, the probability of ending up in each
+	// absorbing state and the expected number of steps until it reaches
+	// a recurrent state (and is absorbed, if every recurrent state is
+	// absorbing). Null if the chain has no absorbing states.
+	Absorption    [][]string `json:"absorption"`
+	ExpectedSteps []string   `json:"expectedSteps"`
+}
+
+// Handles markov requests by validating the supplied matrix of
+// non-negative int literals and returning the analysis of the Markov
+// chain it describes as JSON (see markovChain). The (i, j) entry
+// counts the transitions from state i to state j; the rows are
+// normalized to get the transition probabilities, and states with no
+// transitions out stay put. The results are exact fractions unless the
+// `prec` query parameter asks for decimals, and the `steps` query
+// parameter asks for the n-step transition probabilities, as long as
+// their denominators stay within maxMarkovStepBits bits. Accepts up to
+// maxMarkovStates states. Expects the matrix CSV in the request
+// context.
+func handleMarkov(w h.ResponseWriter, r *h.Request) {
+	prec, err := parseOptPrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	steps := -1
+	if s := r.FormValue("steps"); s != "" {
+		if steps, err = strconv.Atoi(s); err != nil || steps < 0 || steps > maxMarkovSteps {
+			h.Error(w, fmt.Sprintf("Error: steps must be an integer between 0 and %d", maxMarkovSteps),
+				h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+	if len(m) > maxMarkovStates {
+		h.Error(w, fmt.Sprintf("Error: Markov chains are limited to %d states", maxMarkovStates),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	for _, row := range m {
+		for _, d := range row {
+			if d.Sign() < 0 {
+				h.Error(w, "Error: transition counts must be non-negative", h.StatusBadRequest)
+
+				return
+			}
+		}
+	}
+
+	p := transitionMatrix(m)
+	if steps > 0 && steps*denominatorLCM(p).BitLen() > maxMarkovStepBits {
+		h.Error(w, "Error: steps is too large for the transition counts", h.StatusBadRequest)
+
+		return
+	}
+
+	res := markovChain{
+		Transition: rmstrings(p, prec),
+		Recurrent:  []int{},
+		Absorbing:  []int{},
+		Transient:  []int{},
+	}
+
+	if pi := stationary(p); pi != nil {
+		res.Stationary = rvstrings(pi, prec)
+	}
+
+	if steps >= 0 {
+		res.Steps = &steps
+		res.NStep = rmstrings(powRat(p, steps), prec)
+	}
+
+	// A state is transient if it reaches a state that can't reach it
+	// back, and absorbing if it can't be left.
+	reach := closure(m, false)
+	var absorbing, transient []int
+	for i, row := range p {
+		recurrent := true
+		for j := range row {
+			if reach[i][j] && !reach[j][i] {
+				recurrent = false
+
+				break
+			}
+		}
+
+		switch {
+		case !recurrent:
+			transient = append(transient, i)
+			res.Transient = append(res.Transient, i+1)
+		case row[i].Cmp(big.NewRat(1, 1)) == 0:
+			absorbing = append(absorbing, i)
+			res.Absorbing = append(res.Absorbing, i+1)
+			fallthrough
+		default:
+			res.Recurrent = append(res.Recurrent, i+1)
+		}
+	}
+
+	if len(absorbing) > 0 {
+		b, t := absorption(p, absorbing, transient)
+		res.Absorption = rmstrings(b, prec)
+		res.ExpectedSteps = rvstrings(t, prec)
+	}
+
+	writeJSON(w, res)
+}
+
+// Returns the least common multiple of the denominators of the
+// entries of the rational matrix `a`.
+func denominatorLCM(a [][]*big.Rat) *big.Int {
+	lcm, gcd := big.NewInt(1), new(big.Int)
+	for _, row := range a {
+		for _, x := range row {
+			gcd.GCD(nil, nil, lcm, x.Denom())
+			lcm.Mul(lcm, new(big.Int).Quo(x.Denom(), gcd))
+		}
+	}
+
+	return lcm
+}
+
+// Returns the transition probabilities for the transition counts `m`
+// (see handleMarkov).
+func transitionMatrix(m [][]*big.Int) [][]*big.Rat {
+	p := ratMatrix(m)
+
+	for i, row := range m {
+		sum := new(big.Int)
+		for _, d := range row {
+			sum.Add(sum, d)
+		}
+
+		if sum.Sign() == 0 {
+			p[i][i].SetInt64(1)
+
+			continue
+		}
+
+		for j := range p[i] {
+			p[i][j].Quo(p[i][j], new(big.Rat).SetInt(sum))
+		}
+	}
+
+	return p
+}
+
+// Returns the stationary distribution π = π·P of the transition matrix
+// `p`, or nil if it isn't unique.
+func stationary(p [][]*big.Rat) []*big.Rat {
+	n := len(p)
+	if n == 0 {
+		return []*big.Rat{}
+	}
+
+	// Solve (Pᵀ - I)·π = 0 with the last equation replaced by Σπᵢ = 1;
+	// the equations are linearly dependent, and the system is singular
+	// iff the distribution isn't unique.
+	a := transposeRat(p)
+	for i := range a {
+		a[i][i].Sub(a[i][i], big.NewRat(1, 1))
+	}
+	for j := range a[n-1] {
+		a[n-1][j].SetInt64(1)
+	}
+
+	b := newRatMatrix(n, 1)
+	b[n-1][0].SetInt64(1)
+
+	x, err := solveRat(a, b)
+	if err != nil {
+		return nil
+	}
+
+	pi := make([]*big.Rat, n)
+	for i, row := range x {
+		pi[i] = row[0]
+	}
+
+	return pi
+}
+
+// Returns the absorption probabilities B = N·R and the expected steps
+// spent in the transient states t = N·1 of the transition matrix `p`,
+// where N = (I - Q)⁻¹ is the fundamental matrix and Q and R are the
+// transitions from the transient states to the transient and the
+// absorbing ones respectively. N exists since the chain leaves the
+// transient states with probability 1.
+func absorption(p [][]*big.Rat, absorbing, transient []int) (b [][]*big.Rat, t []*big.Rat) {
+	iq := newRatMatrix(len(transient), len(transient))
+	r := newRatMatrix(len(transient), len(absorbing))
+
+	for k, i := range transient {
+		for l, j := range transient {
+			iq[k][l].Neg(p[i][j])
+		}
+		iq[k][k].Add(iq[k][k], big.NewRat(1, 1))
+
+		for l, j := range absorbing {
+			r[k][l].Set(p[i][j])
+		}
+	}
+
+	n, err := inverseRat(iq)
+	if err != nil {
+		panic("fundamental matrix of transient states is singular")
+	}
+
+	t = make([]*big.Rat, len(transient))
+	for k, row := range n {
+		t[k] = new(big.Rat)
+		for _, x := range row {
+			t[k].Add(t[k], x)
+		}
+	}
+
+	return mulRat(n, r), t
+}
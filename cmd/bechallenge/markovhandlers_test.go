@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleMarkov(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"ergodic",
+			"steps=2",
+			[]byte("1,1\n2,0"),
+			`{"transition":[["1/2","1/2"],["1","0"]],"stationary":["2/3","1/3"],` +
+				`"steps":2,"nstep":[["3/4","1/4"],["1/2","1/2"]],"recurrent":[1,2],"absorbing":[],"transient":[],` +
+				`"absorption":null,"expectedSteps":null}` + "\n",
+			200,
+		},
+		{
+			"gamblers-ruin",
+			"",
+			[]byte("1,0,0,0\n1,0,1,0\n0,1,0,1\n0,0,0,1"),
+			`{"transition":[["1","0","0","0"],["1/2","0","1/2","0"],["0","1/2","0","1/2"],["0","0","0","1"]],` +
+				`"stationary":null,"recurrent":[1,4],"absorbing":[1,4],"transient":[2,3],` +
+				`"absorption":[["2/3","1/3"],["1/3","2/3"]],"expectedSteps":["2","2"]}` + "\n",
+			200,
+		},
+		{
+			"no-transitions-out",
+			"prec=3&steps=0",
+			[]byte("0,0\n1,2"),
+			`{"transition":[["1","0"],["0.333","0.667"]],"stationary":["1","0"],` +
+				`"steps":0,"nstep":[["1","0"],["0","1"]],"recurrent":[1],"absorbing":[1],"transient":[2],` +
+				`"absorption":[["1"]],"expectedSteps":["3"]}` + "\n",
+			200,
+		},
+		{
+			"closed-class",
+			"",
+			[]byte("0,1,1,0\n0,1,0,0\n0,0,0,1\n0,0,1,0"),
+			`{"transition":[["0","1/2","1/2","0"],["0","1","0","0"],["0","0","0","1"],["0","0","1","0"]],` +
+				`"stationary":null,"recurrent":[2,3,4],"absorbing":[2],"transient":[1],` +
+				`"absorption":[["1/2"]],"expectedSteps":["1"]}` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			`{"transition":[],"stationary":[],"recurrent":[],"absorbing":[],"transient":[],"absorption":null,"expectedSteps":null}` + "\n",
+			200,
+		},
+		{
+			"negative-count",
+			"",
+			[]byte("1,-1\n0,1"),
+			"Error: transition counts must be non-negative\n",
+			400,
+		},
+		{
+			"invalid-steps",
+			"steps=-1",
+			[]byte("1,1\n2,0"),
+			"Error: steps must be an integer between 0 and 10000\n",
+			400,
+		},
+		{
+			"steps-too-large",
+			"steps=3000",
+			[]byte("1,2\n3,0"),
+			"Error: steps is too large for the transition counts\n",
+			400,
+		},
+		{
+			"too-many-states",
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxMarkovStates)+"1\n", maxMarkovStates+1)),
+			"Error: Markov chains are limited to 16 states\n",
+			400,
+		},
+		{
+			"not-square",
+			"",
+			[]byte("1,1\n2,0\n1,1"),
+			"Error: matrix is not square\n",
+			400,
+		},
+	}
+
+	h := webApiMiddleware(handleMarkov)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
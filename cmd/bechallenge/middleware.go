@@ -7,52 +7,62 @@ import (
 	"fmt"
 	h "net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 )
 
 // Does the prep work common to the handlers in our web API:
 //   - prevent reading in unreasonable amounts of data
 //   - make the payload available as CSV records
+//   - cut out the region selected with the rows, cols and block query
+//     parameters (see sliceRecords)
 //   - handle panics in handler goroutines
 //
 // Reports known error messages to the user. Unexpected error messages
 // only go in the logs as they can contain sensitive info about our infra.
+//
+// The selected region must be a square matrix; it may come from a
+// non-square upload.
 func webApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
+	return csvMiddleware(next, false, true)
+}
+
+// Like webApiMiddleware, for handlers that work entry by entry: the
+// uploaded matrix must be square unless a region is selected from it,
+// and the selected region needn't be square either, so that a
+// selection can skip a header row.
+func entrywiseApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
+	return csvMiddleware(next, true, false)
+}
+
+// Like webApiMiddleware, for handlers that also accept non-square
+// matrices.
+func rectApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
+	return csvMiddleware(next, false, false)
+}
+
+// Implements the middleware for our web API. Rejects non-square
+// uploads without a selection if `squareUnsliced` is set, and
+// non-square selections if `squareSelection` is.
+func csvMiddleware(next h.HandlerFunc, squareUnsliced, squareSelection bool) h.HandlerFunc {
 	handler := func(w h.ResponseWriter, r *h.Request) {
 		r.Body = h.MaxBytesReader(w, r.Body, maxUploadSize)
 
-		f, _, err := r.FormFile("file")
-		if err != nil {
-			if mbe := new(h.MaxBytesError); errors.As(err, &mbe) {
-				m := fmt.Sprintf(
-					"Error: file upload size limit (%d bytes) exceeded",
-					maxUploadSize)
-				h.Error(w, m, h.StatusBadRequest)
-			} else if errors.Is(err, h.ErrNotMultipart) {
-				h.Error(w, "Error: multipart/form-data expected", h.StatusBadRequest)
-			} else {
-				l.Error("getting form file", "err", err)
-				h.Error(w, "Error: unexpected error", h.StatusInternalServerError)
-			}
-
+		recs, ok := formRecords(w, r, "file")
+		if !ok {
 			return
 		}
-		defer f.Close()
 
-		recs, err := csv.NewReader(f).ReadAll()
+		// Cut out the region selected by the query parameters.
+		recs, err := sliceRecords(recs, r)
 		if err != nil {
-			var pe *csv.ParseError
-			if errors.As(err, &pe) {
-				h.Error(w, "Error parsing CSV: "+pe.Error(), h.StatusBadRequest)
-			} else {
-				l.Error("parsing CSV", "err", err)
-				h.Error(w, "Error: unexpected error", h.StatusInternalServerError)
-			}
+			h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
 
 			return
 		}
 
 		// Make sure it's a square matrix.
-		if len(recs) > 0 && len(recs) != len(recs[0]) {
+		if squareUnsliced && !hasSelection(r) && !isSquare(recs) || squareSelection && !isSquare(recs) {
 			h.Error(w, "Error: matrix is not square", h.StatusBadRequest)
 
 			return
@@ -68,6 +78,18 @@ func webApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
 	return recoverer(handler)
 }
 
+// Reports whether the CSV records `recs` form a square matrix. An empty
+// one counts as square.
+func isSquare(recs [][]string) bool {
+	return len(recs) == 0 || len(recs) == len(recs[0])
+}
+
+// Reports whether any of the rows, cols and block query parameters
+// select a region of the upload (see sliceRecords).
+func hasSelection(r *h.Request) bool {
+	return r.FormValue("rows") != "" || r.FormValue("cols") != "" || r.FormValue("block") != ""
+}
+
 // Handles panics by logging the call trace and returning an error
 // response to the user.
 func recoverer(next h.HandlerFunc) h.HandlerFunc {
@@ -82,3 +104,208 @@ func recoverer(next h.HandlerFunc) h.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// Does the prep work common to the handlers in our web API that take
+// their input from the query parameters, and read any form file
+// themselves:
+//   - prevent reading in unreasonable amounts of data
+//   - handle panics in handler goroutines
+//
+// The form is parsed up front, so that the size limit applies before
+// the handlers first look at a query parameter.
+func queryApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
+	return recoverer(func(w h.ResponseWriter, r *h.Request) {
+		r.Body = h.MaxBytesReader(w, r.Body, maxUploadSize)
+
+		err := r.ParseMultipartForm(maxUploadSize)
+		if err != nil && !errors.Is(err, h.ErrNotMultipart) {
+			reportFormError(w, err, "")
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Like rectApiMiddleware, for handlers that operate on two matrices.
+// The second one is uploaded as the "file2" form file and made
+// available to the handlers under the csvRecords2Key context key. The
+// rows, cols and block query parameters only apply to the first one.
+func binaryApiMiddleware(next h.HandlerFunc) h.HandlerFunc {
+	return rectApiMiddleware(func(w h.ResponseWriter, r *h.Request) {
+		recs, ok := formRecords(w, r, "file2")
+		if !ok {
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), csvRecords2Key, recs)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Reads the CSV records from the form file `field`. Reports errors to
+// the user; the caller should return if `ok` is false.
+func formRecords(w h.ResponseWriter, r *h.Request, field string) (recs [][]string, ok bool) {
+	f, _, err := r.FormFile(field)
+	if err != nil {
+		reportFormError(w, err, field)
+
+		return nil, false
+	}
+	defer f.Close()
+
+	recs, err = csv.NewReader(f).ReadAll()
+	if err != nil {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			h.Error(w, "Error parsing CSV: "+pe.Error(), h.StatusBadRequest)
+		} else {
+			l.Error("parsing CSV", "err", err)
+			h.Error(w, "Error: unexpected error", h.StatusInternalServerError)
+		}
+
+		return nil, false
+	}
+
+	return recs, true
+}
+
+// Reports the error `err` from parsing the form or getting the form
+// file `field` to the user.
+func reportFormError(w h.ResponseWriter, err error, field string) {
+	if mbe := new(h.MaxBytesError); errors.As(err, &mbe) {
+		m := fmt.Sprintf(
+			"Error: file upload size limit (%d bytes) exceeded",
+			maxUploadSize)
+		h.Error(w, m, h.StatusBadRequest)
+	} else if errors.Is(err, h.ErrNotMultipart) {
+		h.Error(w, "Error: multipart/form-data expected", h.StatusBadRequest)
+	} else if errors.Is(err, h.ErrMissingFile) {
+		h.Error(w, fmt.Sprintf("Error: form file %q expected", field), h.StatusBadRequest)
+	} else {
+		l.Error("parsing form", "err", err)
+		h.Error(w, "Error: unexpected error", h.StatusInternalServerError)
+	}
+}
+
+// Cuts out the rows and columns of `recs` selected by the query
+// parameters of `r`:
+//
+//	rows=1:5        rows 1 through 5
+//	cols=2,4,7:     columns 2, 4 and 7 through the last one
+//	block=1:2,3:4   shorthand for rows=1:2&cols=3:4
+//
+// Indices are 1-based and ranges are inclusive; either end of a range
+// can be left out. Returns `recs` as is if nothing is selected.
+func sliceRecords(recs [][]string, r *h.Request) ([][]string, error) {
+	rows, cols, block := r.FormValue("rows"), r.FormValue("cols"), r.FormValue("block")
+
+	if block != "" {
+		if rows != "" || cols != "" {
+			return nil, errors.New("block can't be combined with rows or cols")
+		}
+
+		var ok bool
+		if rows, cols, ok = strings.Cut(block, ","); !ok || strings.Contains(cols, ",") {
+			return nil, errors.New("block must be of the form r0:r1,c0:c1")
+		}
+	}
+
+	if rows != "" {
+		idx, err := parseSelection(rows, len(recs))
+		if err != nil {
+			return nil, fmt.Errorf("rows: %v", err)
+		}
+
+		sel := make([][]string, len(idx))
+		for i, ri := range idx {
+			sel[i] = recs[ri]
+		}
+		recs = sel
+	}
+
+	if cols != "" {
+		n := 0
+		if len(recs) > 0 {
+			n = len(recs[0])
+		}
+
+		idx, err := parseSelection(cols, n)
+		if err != nil {
+			return nil, fmt.Errorf("cols: %v", err)
+		}
+
+		sel := make([][]string, len(recs))
+		for i, rec := range recs {
+			sel[i] = make([]string, len(idx))
+			for j, ci := range idx {
+				sel[i][j] = rec[ci]
+			}
+		}
+		recs = sel
+	}
+
+	return recs, nil
+}
+
+// Parses the comma separated list of 1-based indices and inclusive
+// ranges `s` selecting from `n` items. Returns the selected 0-based
+// indices in order. Items may repeat indices, but the selection can't
+// be longer than `n` so that it never outgrows the upload.
+func parseSelection(s string, n int) ([]int, error) {
+	if n == 0 {
+		return nil, errors.New("nothing to select from")
+	}
+
+	var idx []int
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty item in %q", s)
+		}
+
+		lo, hi, isRange := strings.Cut(item, ":")
+
+		first, last := 1, n
+		var err error
+		if lo != "" {
+			if first, err = parseIndex(lo, n); err != nil {
+				return nil, err
+			}
+		}
+		if !isRange {
+			last = first
+		} else if hi != "" {
+			if last, err = parseIndex(hi, n); err != nil {
+				return nil, err
+			}
+		}
+		if last < first {
+			return nil, fmt.Errorf("empty range %q", item)
+		}
+		if len(idx)+last-first+1 > n {
+			return nil, fmt.Errorf("selection picks more than %d indices", n)
+		}
+
+		for i := first; i <= last; i++ {
+			idx = append(idx, i-1)
+		}
+	}
+
+	return idx, nil
+}
+
+// Parses the 1-based index `s` into one of `n` items.
+func parseIndex(s string, n int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %q", s)
+	}
+	if i < 1 || i > n {
+		return 0, fmt.Errorf("index %d out of range (1-%d)", i, n)
+	}
+
+	return i, nil
+}
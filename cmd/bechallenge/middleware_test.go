@@ -72,3 +72,259 @@ func TestRecoverer(t *testing.T) {
 		t.Errorf("Status code mismatch: got %d; want 500", w.Code)
 	}
 }
+
+func TestRectApiMiddleware(t *testing.T) {
+	tests := []formFileTestCase{
+		{
+			"non-square-matrix",
+			[]byte("1,2,3"),
+			"",
+			200,
+		},
+		{
+			"invalid-csv",
+			[]byte("1,2,3\n4,3,5,7,8,9,"),
+			"Error parsing CSV: record on line 2: wrong number of fields\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(func(http.ResponseWriter, *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runFormFileTestCase(t, h, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestQueryApiMiddleware(t *testing.T) {
+	tests := []formFileTestCase{
+		{
+			"huge-file",
+			make([]byte, maxUploadSize+1),
+			"Error: file upload size limit (10485760 bytes) exceeded\n",
+			400,
+		},
+		{
+			"small-file",
+			[]byte("1,2,3"),
+			"",
+			200,
+		},
+	}
+
+	h := queryApiMiddleware(func(http.ResponseWriter, *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runFormFileTestCase(t, h, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+
+	t.Run("no-body", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/?n=1", nil)
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != 200 {
+			t.Errorf("Status code mismatch: got %d; want 200", w.Code)
+		}
+	})
+}
+
+func TestSliceRecords(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"rows-open-range",
+			"rows=2:",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"4,5,6\n7,8,9\n",
+			200,
+		},
+		{
+			"cols-list-and-range",
+			"cols=3,1:2",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"3,1,2\n6,4,5\n9,7,8\n",
+			200,
+		},
+		{
+			"rows-and-cols",
+			"rows=:2&cols=2",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"2\n5\n",
+			200,
+		},
+		{
+			"block",
+			"block=2:3,2:3",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"5,6\n8,9\n",
+			200,
+		},
+		{
+			"block-with-rows",
+			"block=1:2,1:2&rows=1",
+			[]byte("1,2\n3,4"),
+			"Error: block can't be combined with rows or cols\n",
+			400,
+		},
+		{
+			"malformed-block",
+			"block=1:2",
+			[]byte("1,2\n3,4"),
+			"Error: block must be of the form r0:r1,c0:c1\n",
+			400,
+		},
+		{
+			"index-out-of-range",
+			"cols=4",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"Error: cols: index 4 out of range (1-3)\n",
+			400,
+		},
+		{
+			"invalid-index",
+			"rows=a:",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"Error: rows: invalid index \"a\"\n",
+			400,
+		},
+		{
+			"empty-range",
+			"rows=3:2",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"Error: rows: empty range \"3:2\"\n",
+			400,
+		},
+		{
+			"empty-item",
+			"rows=1,,2",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"Error: rows: empty item in \"1,,2\"\n",
+			400,
+		},
+		{
+			"trailing-comma",
+			"cols=1,",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"Error: cols: empty item in \"1,\"\n",
+			400,
+		},
+		{
+			"repeated-indices",
+			"cols=2,2,2",
+			[]byte("1,2,3\n4,5,6"),
+			"2,2,2\n5,5,5\n",
+			200,
+		},
+		{
+			"selection-longer-than-axis",
+			"cols=1:,1:",
+			[]byte("1,2,3\n4,5,6"),
+			"Error: cols: selection picks more than 3 indices\n",
+			400,
+		},
+		{
+			"empty-upload-open-range",
+			"rows=2:",
+			[]byte{},
+			"Error: rows: nothing to select from\n",
+			400,
+		},
+		{
+			"empty-upload-empty-item",
+			"rows=,",
+			[]byte{},
+			"Error: rows: nothing to select from\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleEcho)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+// Entrywise operations need a square upload, but not a square
+// selection; the others need a square selection, but not a square
+// upload.
+func TestSliceRecordsSquare(t *testing.T) {
+	tests := []struct {
+		name       string
+		middleware func(http.HandlerFunc) http.HandlerFunc
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"header-row-skipped",
+			entrywiseApiMiddleware,
+			handleSum,
+			"rows=2:",
+			[]byte("a,b,c\n1,2,3\n4,5,6"),
+			"21\n",
+			200,
+		},
+		{
+			"header-row-over-square-data",
+			entrywiseApiMiddleware,
+			handleSum,
+			"rows=2:",
+			[]byte("a,b\n1,2\n3,4"),
+			"10\n",
+			200,
+		},
+		{
+			"upload-not-square-selected",
+			entrywiseApiMiddleware,
+			handleSum,
+			"rows=1:2",
+			[]byte("1,2\n3,4\n5,6"),
+			"10\n",
+			200,
+		},
+		{
+			"upload-not-square-unsliced",
+			entrywiseApiMiddleware,
+			handleSum,
+			"",
+			[]byte("1,2\n3,4\n5,6"),
+			"Error: matrix is not square\n",
+			400,
+		},
+		{
+			"selection-square",
+			webApiMiddleware,
+			handlePermanent,
+			"rows=2:",
+			[]byte("a,b\n1,2\n3,4"),
+			"10\n",
+			200,
+		},
+		{
+			"selection-not-square",
+			webApiMiddleware,
+			handlePermanent,
+			"rows=1",
+			[]byte("1,2\n3,4"),
+			"Error: matrix is not square\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.middleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
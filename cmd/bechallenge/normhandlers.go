@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+// A matrix norm or condition number. Value is an exact integer or
+// fraction if Exact is set, or a decimal with the requested precision
+// otherwise.
+type normResult struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Exact bool   `json:"exact"`
+	// The exact square of the Frobenius norm.
+	Squared string `json:"squared,omitempty"`
+}
+
+// Handles norm requests by validating the supplied matrix of int
+// literals and returning its norm as JSON. The `type` query parameter
+// selects the norm:
+//   - 1: the maximum absolute column sum
+//   - inf: the maximum absolute row sum
+//   - max: the maximum absolute entry
+//   - frobenius: the square root of the sum of squared entries
+//   - 2: the spectral norm (the largest singular value)
+//
+// The 1, inf and max norms are exact integers, as is the square of the
+// Frobenius norm. The Frobenius and spectral norms are computed with
+// `prec` significant digits; the latter is limited to matrices of up to
+// maxSVDSize rows and columns. Accepts non-square matrices. Expects the
+// matrix CSV in the request context.
+func handleNorm(w h.ResponseWriter, r *h.Request) {
+	prec, err := parsePrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	typ := r.FormValue("type")
+	if typ == "" {
+		typ = "frobenius"
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	res := normResult{Type: typ, Exact: true}
+
+	switch typ {
+	case "1", "inf", "max":
+		res.Value = intNorm(m, typ).String()
+	case "frobenius":
+		sq := new(big.Int)
+		for _, row := range m {
+			for _, d := range row {
+				sq.Add(sq, new(big.Int).Mul(d, d))
+			}
+		}
+
+		res.Squared = sq.String()
+		res.Value = sqrtRat(new(big.Rat).SetInt(sq), prec)
+		res.Exact = false
+	case "2":
+		if !checkSVDSize(w, m) {
+			return
+		}
+
+		res.Value = "0"
+		if sv := singularValues(ratMatrix(m), precBits(prec)); len(sv) > 0 {
+			res.Value = ftos(sv[0], prec)
+		}
+		res.Exact = false
+	default:
+		h.Error(w, fmt.Sprintf("Error: unknown norm type %q", typ), h.StatusBadRequest)
+
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// Handles cond requests by validating the supplied matrix of int
+// literals and returning its condition number ‖A‖·‖A⁻¹‖ as JSON. The
+// `type` query parameter selects the norm (1, inf or 2, see
+// handleNorm). The 1 and inf condition numbers are exact fractions, and
+// the inverse they take limits the matrix to maxCofactorSize rows; the
+// spectral one is computed with `prec` significant digits, and is
+// limited as the spectral norm is. The condition number of a singular
+// matrix is +Inf. Expects the matrix CSV in the request context.
+func handleCond(w h.ResponseWriter, r *h.Request) {
+	prec, err := parsePrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	typ := r.FormValue("type")
+	if typ == "" {
+		typ = "2"
+	}
+	if typ != "1" && typ != "inf" && typ != "2" {
+		h.Error(w, fmt.Sprintf("Error: unknown norm type %q", typ), h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok || typ == "2" && !checkSVDSize(w, m) {
+		return
+	}
+	if len(m) > maxCofactorSize {
+		h.Error(w, fmt.Sprintf("Error: matrix is limited to %dx%d", maxCofactorSize, maxCofactorSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	res := normResult{Type: typ, Exact: typ != "2"}
+
+	a := ratMatrix(m)
+	inv, err := inverseRat(a)
+	if err != nil {
+		res.Value = "+Inf"
+		writeJSON(w, res)
+
+		return
+	}
+
+	if typ == "2" {
+		// σ_max(A)·σ_max(A⁻¹) = σ_max(A)/σ_min(A)
+		bits := precBits(prec)
+		sv, svInv := singularValues(a, bits), singularValues(inv, bits)
+
+		res.Value = "1"
+		if len(sv) > 0 {
+			res.Value = ftos(new(big.Float).Mul(sv[0], svInv[0]), prec)
+		}
+	} else {
+		cond := new(big.Rat).SetInt(intNorm(m, typ))
+		res.Value = cond.Mul(cond, ratNorm(inv, typ)).RatString()
+	}
+
+	writeJSON(w, res)
+}
+
+// Returns the 1, inf or max norm of the integer matrix `m`.
+func intNorm(m [][]*big.Int, typ string) *big.Int {
+	return ratNorm(ratMatrix(m), typ).Num()
+}
+
+// Returns the 1, inf or max norm of the rational matrix `a`.
+func ratNorm(a [][]*big.Rat, typ string) *big.Rat {
+	if typ == "1" {
+		a = transposeRat(a)
+	}
+
+	norm := new(big.Rat)
+	for _, row := range a {
+		s := new(big.Rat)
+		for _, x := range row {
+			ax := new(big.Rat).Abs(x)
+			if typ == "max" {
+				if ax.Cmp(norm) > 0 {
+					norm.Set(ax)
+				}
+			} else {
+				s.Add(s, ax)
+			}
+		}
+
+		if s.Cmp(norm) > 0 {
+			norm.Set(s)
+		}
+	}
+
+	return norm
+}
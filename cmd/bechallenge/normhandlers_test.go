@@ -0,0 +1,170 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"norm-1",
+			handleNorm,
+			"type=1",
+			[]byte("1,-2\n-3,4"),
+			`{"type":"1","value":"6","exact":true}` + "\n",
+			200,
+		},
+		{
+			"norm-inf",
+			handleNorm,
+			"type=inf",
+			[]byte("1,-2\n-3,4"),
+			`{"type":"inf","value":"7","exact":true}` + "\n",
+			200,
+		},
+		{
+			"norm-max",
+			handleNorm,
+			"type=max",
+			[]byte("1,-2\n-12345678901234567890,4"),
+			`{"type":"max","value":"12345678901234567890","exact":true}` + "\n",
+			200,
+		},
+		{
+			"norm-frobenius",
+			handleNorm,
+			"prec=10",
+			[]byte("1,-2\n-3,4"),
+			`{"type":"frobenius","value":"5.477225575","exact":false,"squared":"30"}` + "\n",
+			200,
+		},
+		{
+			"norm-2",
+			handleNorm,
+			"type=2&prec=15",
+			[]byte("3,0\n4,5"),
+			`{"type":"2","value":"6.70820393249937","exact":false}` + "\n",
+			200,
+		},
+		{
+			"norm-2-non-square",
+			handleNorm,
+			"type=2&prec=10",
+			[]byte("3,4"),
+			`{"type":"2","value":"5","exact":false}` + "\n",
+			200,
+		},
+		{
+			"norm-2-badly-scaled",
+			handleNorm,
+			"type=2&prec=10",
+			[]byte("1000000000000000000000000000000,1\n1,1"),
+			`{"type":"2","value":"1e+30","exact":false}` + "\n",
+			200,
+		},
+		{
+			"norm-empty-csv",
+			handleNorm,
+			"type=2",
+			[]byte{},
+			`{"type":"2","value":"0","exact":false}` + "\n",
+			200,
+		},
+		{
+			"norm-2-too-large",
+			handleNorm,
+			"type=2",
+			[]byte(strings.Repeat("1,", maxSVDSize) + "1"),
+			"Error: matrix is limited to 16x16\n",
+			400,
+		},
+		{
+			"norm-unknown-type",
+			handleNorm,
+			"type=nuclear",
+			[]byte("1"),
+			"Error: unknown norm type \"nuclear\"\n",
+			400,
+		},
+		{
+			"cond-1",
+			handleCond,
+			"type=1",
+			[]byte("1,2\n3,4"),
+			`{"type":"1","value":"21","exact":true}` + "\n",
+			200,
+		},
+		{
+			"cond-inf",
+			handleCond,
+			"type=inf",
+			[]byte("2,0\n0,3"),
+			`{"type":"inf","value":"3/2","exact":true}` + "\n",
+			200,
+		},
+		{
+			"cond-2",
+			handleCond,
+			"prec=15",
+			[]byte("3,0\n4,5"),
+			`{"type":"2","value":"3","exact":false}` + "\n",
+			200,
+		},
+		{
+			"cond-nearly-parallel-columns",
+			handleCond,
+			"prec=10",
+			[]byte("1000000000000000000000000000000,1000000000000000000000000000001\n1000000000000000000000000000000,1000000000000000000000000000000"),
+			`{"type":"2","value":"4e+30","exact":false}` + "\n",
+			200,
+		},
+		{
+			"cond-singular",
+			handleCond,
+			"",
+			[]byte("1,2\n2,4"),
+			`{"type":"2","value":"+Inf","exact":false}` + "\n",
+			200,
+		},
+		{
+			"cond-2-too-large",
+			handleCond,
+			"",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxSVDSize)+"1\n", maxSVDSize+1)),
+			"Error: matrix is limited to 16x16\n",
+			400,
+		},
+		{
+			"cond-1-too-large",
+			handleCond,
+			"type=1",
+			[]byte(strings.Repeat(strings.Repeat("1,", maxCofactorSize)+"1\n", maxCofactorSize+1)),
+			"Error: matrix is limited to 48x48\n",
+			400,
+		},
+		{
+			"cond-unknown-type",
+			handleCond,
+			"type=max",
+			[]byte("1"),
+			"Error: unknown norm type \"max\"\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := rectApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
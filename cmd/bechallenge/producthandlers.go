@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+// Caps the number of entries of Kronecker products and direct sums.
+const maxProductSize = 1 << 22
+
+// Handles kronecker requests by validating the two supplied matrices of
+// int literals and returning their Kronecker product. The output can
+// be very large, so it's streamed row by row, and limited to
+// maxProductSize entries. Expects the matrix CSVs in the request
+// context (see binaryApiMiddleware).
+func handleKronecker(w h.ResponseWriter, r *h.Request) {
+	a, b, ok := parseMatrices(w, r)
+	if !ok {
+		return
+	}
+	if len(a)*ncols(a)*len(b)*ncols(b) > maxProductSize {
+		h.Error(w, fmt.Sprintf("Error: the product is limited to %d entries", maxProductSize), h.StatusBadRequest)
+
+		return
+	}
+
+	bRows, bCols := len(b), ncols(b)
+
+	streamMatrix(w, len(a)*bRows, func(i int) []*big.Int {
+		ar, br := a[i/bRows], b[i%bRows]
+
+		row := make([]*big.Int, len(ar)*bCols)
+		for j := range row {
+			row[j] = new(big.Int).Mul(ar[j/bCols], br[j%bCols])
+		}
+
+		return row
+	})
+}
+
+// Handles hadamard requests by validating the two supplied matrices of
+// int literals and returning their elementwise product. The matrices
+// must have the same shape. Expects the matrix CSVs in the request
+// context (see binaryApiMiddleware).
+func handleHadamard(w h.ResponseWriter, r *h.Request) {
+	a, b, ok := parseMatrices(w, r)
+	if !ok {
+		return
+	}
+
+	if len(a) != len(b) || ncols(a) != ncols(b) {
+		h.Error(w, "Error: matrices must have the same shape", h.StatusBadRequest)
+
+		return
+	}
+
+	streamMatrix(w, len(a), func(i int) []*big.Int {
+		row := make([]*big.Int, len(a[i]))
+		for j := range row {
+			row[j] = new(big.Int).Mul(a[i][j], b[i][j])
+		}
+
+		return row
+	})
+}
+
+// Handles directsum requests by validating the two supplied matrices of
+// int literals and returning their direct sum: the block diagonal
+// matrix with the first one in the top left and the second one in the
+// bottom right corner. The output is limited to maxProductSize
+// entries. Expects the matrix CSVs in the request context (see
+// binaryApiMiddleware).
+func handleDirectSum(w h.ResponseWriter, r *h.Request) {
+	a, b, ok := parseMatrices(w, r)
+	if !ok {
+		return
+	}
+
+	aCols, cols := ncols(a), ncols(a)+ncols(b)
+	if (len(a)+len(b))*cols > maxProductSize {
+		h.Error(w, fmt.Sprintf("Error: the direct sum is limited to %d entries", maxProductSize), h.StatusBadRequest)
+
+		return
+	}
+	zero := new(big.Int)
+
+	streamMatrix(w, len(a)+len(b), func(i int) []*big.Int {
+		row := make([]*big.Int, cols)
+		for j := range row {
+			row[j] = zero
+		}
+
+		if i < len(a) {
+			copy(row, a[i])
+		} else {
+			copy(row[aCols:], b[i-len(a)])
+		}
+
+		return row
+	})
+}
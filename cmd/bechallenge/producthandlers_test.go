@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProductHandlers(t *testing.T) {
+	tests := []struct {
+		twoFileTestCase
+		handler func(http.ResponseWriter, *http.Request)
+	}{
+		{
+			twoFileTestCase{
+				"kronecker",
+				"",
+				[]byte("1,2\n3,4"),
+				[]byte("0,5\n6,7"),
+				"0,5,0,10\n6,7,12,14\n0,15,0,20\n18,21,24,28\n",
+				200,
+			},
+			handleKronecker,
+		},
+		{
+			twoFileTestCase{
+				"kronecker-non-square",
+				"",
+				[]byte("1,-1"),
+				[]byte("2\n3"),
+				"2,-2\n3,-3\n",
+				200,
+			},
+			handleKronecker,
+		},
+		{
+			twoFileTestCase{
+				"kronecker-sliced",
+				"cols=2",
+				[]byte("1,2\n3,4"),
+				[]byte("1,1"),
+				"2,2\n4,4\n",
+				200,
+			},
+			handleKronecker,
+		},
+		{
+			twoFileTestCase{
+				"kronecker-too-large",
+				"",
+				[]byte(strings.Repeat("1,", 2048) + "1"),
+				[]byte(strings.Repeat("1,", 2047) + "1"),
+				"Error: the product is limited to 4194304 entries\n",
+				400,
+			},
+			handleKronecker,
+		},
+		{
+			twoFileTestCase{
+				"hadamard",
+				"",
+				[]byte("1,2\n3,4"),
+				[]byte("5,6\n7,-12345678901234567890"),
+				"5,12\n21,-49382715604938271560\n",
+				200,
+			},
+			handleHadamard,
+		},
+		{
+			twoFileTestCase{
+				"hadamard-shape-mismatch",
+				"",
+				[]byte("1,2\n3,4"),
+				[]byte("5,6"),
+				"Error: matrices must have the same shape\n",
+				400,
+			},
+			handleHadamard,
+		},
+		{
+			twoFileTestCase{
+				"directsum",
+				"",
+				[]byte("1,2\n3,4"),
+				[]byte("5"),
+				"1,2,0\n3,4,0\n0,0,5\n",
+				200,
+			},
+			handleDirectSum,
+		},
+		{
+			twoFileTestCase{
+				"directsum-too-large",
+				"",
+				[]byte(strings.Repeat("1,", 2047) + "1"),
+				[]byte(strings.Repeat("1\n", 2048)),
+				"Error: the direct sum is limited to 4194304 entries\n",
+				400,
+			},
+			handleDirectSum,
+		},
+		{
+			twoFileTestCase{
+				"directsum-empty",
+				"",
+				[]byte{},
+				[]byte{},
+				"\n",
+				200,
+			},
+			handleDirectSum,
+		},
+		{
+			twoFileTestCase{
+				"missing-second-file",
+				"",
+				[]byte("1"),
+				nil,
+				"Error: form file \"file2\" expected\n",
+				400,
+			},
+			handleHadamard,
+		},
+		{
+			twoFileTestCase{
+				"invalid-second-file",
+				"",
+				[]byte("1"),
+				[]byte("x"),
+				"Error: parsing CSV (file2): record on line 1: parsing \"x\": invalid syntax\n",
+				400,
+			},
+			handleKronecker,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{"file": tt.payload}
+			if tt.payload2 != nil {
+				files["file2"] = tt.payload2
+			}
+
+			h := binaryApiMiddleware(tt.handler)
+			runFormFilesTestCase(t, h, tt.query, files, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
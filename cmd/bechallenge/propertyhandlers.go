@@ -0,0 +1,223 @@
+package main
+
+import (
+	"math/big"
+	h "net/http"
+)
+
+const (
+	// Caps the size of the matrices for which we check the properties
+	// that require matrix products (idempotent, nilpotent). These are
+	// O(n³) big.Int operations and would take too long on large
+	// uploads.
+	maxProductCheckSize = 128
+
+	// Caps the cost of each of those products, estimated as n³ times
+	// the square of the size of the entries in words. The entries of
+	// the powers of a matrix double in size with every squaring, so
+	// this also bounds the number of squarings of the nilpotent check.
+	maxProductWork = 1 << 25
+)
+
+// A report of the structural properties of a matrix. The square-only
+// properties are false for non-square matrices. Idempotent and
+// nilpotent are null for non-square matrices, for matrices larger than
+// maxProductCheckSize, and when their products would cost more than
+// maxProductWork. Magic and LatinSquare follow the definitions of the
+// puzzle validator (see handlePuzzleValidate), so their entries must be
+// 1 to n² and 1 to n respectively.
+type properties struct {
+	Rows            int    `json:"rows"`
+	Cols            int    `json:"cols"`
+	Square          bool   `json:"square"`
+	Symmetric       bool   `json:"symmetric"`
+	SkewSymmetric   bool   `json:"skewSymmetric"`
+	Diagonal        bool   `json:"diagonal"`
+	UpperTriangular bool   `json:"upperTriangular"`
+	LowerTriangular bool   `json:"lowerTriangular"`
+	Identity        bool   `json:"identity"`
+	Permutation     bool   `json:"permutation"`
+	Orthogonal      bool   `json:"orthogonal"`
+	Idempotent      *bool  `json:"idempotent"`
+	Nilpotent       *bool  `json:"nilpotent"`
+	Magic           bool   `json:"magic"`
+	LatinSquare     bool   `json:"latinSquare"`
+	Density         string `json:"density"`
+	Sparsity        string `json:"sparsity"`
+	// Omitted for non-square matrices, and a string so that JSON
+	// clients don't lose precision.
+	Trace     string `json:"trace,omitempty"`
+	MinBitLen int    `json:"minBitLen"`
+	MaxBitLen int    `json:"maxBitLen"`
+}
+
+// Handles properties requests by validating the supplied matrix of int
+// literals and returning a JSON report of its structural properties
+// (see properties). Accepts non-square matrices. Expects the matrix CSV
+// in the request context.
+func handleProperties(w h.ResponseWriter, r *h.Request) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, matrixProperties(m))
+}
+
+// Computes the properties report of the matrix `m`.
+func matrixProperties(m [][]*big.Int) properties {
+	p := properties{Rows: len(m), Cols: ncols(m)}
+	p.Square = p.Rows == p.Cols
+
+	// Entry based properties.
+	var nonzero int64
+	p.MinBitLen = -1
+	for _, row := range m {
+		for _, d := range row {
+			if d.Sign() != 0 {
+				nonzero++
+			}
+			if bl := d.BitLen(); p.MinBitLen < 0 || bl < p.MinBitLen {
+				p.MinBitLen = bl
+			}
+			p.MaxBitLen = max(p.MaxBitLen, d.BitLen())
+		}
+	}
+	p.MinBitLen = max(p.MinBitLen, 0)
+
+	p.Density, p.Sparsity = "0", "0"
+	if total := int64(p.Rows * p.Cols); total > 0 {
+		density := big.NewRat(nonzero, total)
+		p.Density = density.RatString()
+		p.Sparsity = new(big.Rat).Sub(big.NewRat(1, 1), density).RatString()
+	}
+
+	if !p.Square {
+		return p
+	}
+
+	trace := new(big.Int)
+	p.Symmetric, p.SkewSymmetric = true, true
+	p.Diagonal, p.UpperTriangular, p.LowerTriangular = true, true, true
+	p.Identity = true
+
+	neg := new(big.Int)
+	for i, row := range m {
+		trace.Add(trace, row[i])
+
+		for j, d := range row {
+			if d.Cmp(m[j][i]) != 0 {
+				p.Symmetric = false
+			}
+			if d.Cmp(neg.Neg(m[j][i])) != 0 {
+				p.SkewSymmetric = false
+			}
+
+			if d.Sign() != 0 {
+				if i != j {
+					p.Diagonal, p.Identity = false, false
+				}
+				if i > j {
+					p.UpperTriangular = false
+				}
+				if i < j {
+					p.LowerTriangular = false
+				}
+			}
+			if i == j && d.Cmp(big.NewInt(1)) != 0 {
+				p.Identity = false
+			}
+		}
+	}
+
+	p.Trace = trace.String()
+	p.Permutation = isSignedPermutation(m, false)
+	// Integer orthogonal matrices are exactly the signed permutation
+	// matrices: each row must be a unit vector.
+	p.Orthogonal = isSignedPermutation(m, true)
+	// The same definitions as the puzzle validator's.
+	p.Magic = solvesPuzzle(m, "magic")
+	p.LatinSquare = solvesPuzzle(m, "latin")
+
+	if p.Rows <= maxProductCheckSize && cheapProduct(m) {
+		idempotent := equalInt(mulInt(m, m), m)
+		p.Idempotent = &idempotent
+
+		if nilpotent, ok := isNilpotent(m); ok {
+			p.Nilpotent = &nilpotent
+		}
+	}
+
+	return p
+}
+
+// Reports whether the square matrix `m` has exactly one non-zero entry
+// in each row and column, and that entry is 1 (or ±1 if `signed`).
+func isSignedPermutation(m [][]*big.Int, signed bool) bool {
+	seen := make([]bool, len(m))
+
+	for _, row := range m {
+		col := -1
+		for j, d := range row {
+			if d.Sign() == 0 {
+				continue
+			}
+			if col >= 0 || d.CmpAbs(big.NewInt(1)) != 0 || (!signed && d.Sign() < 0) {
+				return false
+			}
+			col = j
+		}
+
+		if col < 0 || seen[col] {
+			return false
+		}
+		seen[col] = true
+	}
+
+	return true
+}
+
+// Reports whether some power of the square matrix `m` is zero. The
+// nilpotency index of an n x n matrix is at most n, so it's enough to
+// square `m` until the exponent reaches n. Returns false for `ok` if
+// the powers grow too large to square (see cheapProduct).
+func isNilpotent(m [][]*big.Int) (nilpotent, ok bool) {
+	// The eigenvalues of a nilpotent matrix, and so its trace, are 0.
+	trace := new(big.Int)
+	for i, row := range m {
+		trace.Add(trace, row[i])
+	}
+	if trace.Sign() != 0 {
+		return false, true
+	}
+
+	p := m
+	for k := 1; ; k *= 2 {
+		if isZeroInt(p) {
+			return true, true
+		}
+		if k >= len(m) {
+			return false, true
+		}
+		if !cheapProduct(p) {
+			return false, false
+		}
+
+		p = mulInt(p, p)
+	}
+}
+
+// Reports whether the product of the square matrix `m` with a matrix
+// of the same size and entries is within maxProductWork.
+func cheapProduct(m [][]*big.Int) bool {
+	var bits int
+	for _, row := range m {
+		for _, d := range row {
+			bits = max(bits, d.BitLen())
+		}
+	}
+
+	n, words := int64(len(m)), int64(bits+63)/64
+
+	return n*n*n*words*words <= maxProductWork
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestHandleProperties(t *testing.T) {
+	// Entries too large to multiply quickly, and entries whose square
+	// is.
+	huge := new(big.Int).Lsh(big.NewInt(1), 200000)
+	large := new(big.Int).Lsh(big.NewInt(1), 30000)
+
+	tests := []formFileTestCase{
+		{
+			"identity",
+			[]byte("1,0\n0,1"),
+			`{"rows":2,"cols":2,"square":true,"symmetric":true,"skewSymmetric":false,` +
+				`"diagonal":true,"upperTriangular":true,"lowerTriangular":true,"identity":true,` +
+				`"permutation":true,"orthogonal":true,"idempotent":true,"nilpotent":false,` +
+				`"magic":false,"latinSquare":false,"density":"1/2","sparsity":"1/2","trace":"2",` +
+				`"minBitLen":0,"maxBitLen":1}` + "\n",
+			200,
+		},
+		{
+			"skew-symmetric-nilpotent",
+			[]byte("0,1\n-1,0"),
+			`{"rows":2,"cols":2,"square":true,"symmetric":false,"skewSymmetric":true,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":true,"idempotent":false,"nilpotent":false,` +
+				`"magic":false,"latinSquare":false,"density":"1/2","sparsity":"1/2","trace":"0",` +
+				`"minBitLen":0,"maxBitLen":1}` + "\n",
+			200,
+		},
+		{
+			"strictly-upper-triangular",
+			[]byte("0,1,2\n0,0,3\n0,0,0"),
+			`{"rows":3,"cols":3,"square":true,"symmetric":false,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":true,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":false,"nilpotent":true,` +
+				`"magic":false,"latinSquare":false,"density":"1/3","sparsity":"2/3","trace":"0",` +
+				`"minBitLen":0,"maxBitLen":2}` + "\n",
+			200,
+		},
+		{
+			"magic-latin",
+			[]byte("2,7,6\n9,5,1\n4,3,8"),
+			`{"rows":3,"cols":3,"square":true,"symmetric":false,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":false,"nilpotent":false,` +
+				`"magic":true,"latinSquare":false,"density":"1","sparsity":"0","trace":"15",` +
+				`"minBitLen":1,"maxBitLen":4}` + "\n",
+			200,
+		},
+		{
+			"equal-sums-not-magic",
+			[]byte("2,2\n2,2"),
+			`{"rows":2,"cols":2,"square":true,"symmetric":true,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":false,"nilpotent":false,` +
+				`"magic":false,"latinSquare":false,"density":"1","sparsity":"0","trace":"4",` +
+				`"minBitLen":2,"maxBitLen":2}` + "\n",
+			200,
+		},
+		{
+			"latin-square",
+			[]byte("1,2,3\n2,3,1\n3,1,2"),
+			`{"rows":3,"cols":3,"square":true,"symmetric":true,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":false,"nilpotent":false,` +
+				`"magic":false,"latinSquare":true,"density":"1","sparsity":"0","trace":"6",` +
+				`"minBitLen":1,"maxBitLen":2}` + "\n",
+			200,
+		},
+		{
+			"idempotent-projection",
+			[]byte("1,1\n0,0"),
+			`{"rows":2,"cols":2,"square":true,"symmetric":false,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":true,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":true,"nilpotent":false,` +
+				`"magic":false,"latinSquare":false,"density":"1/2","sparsity":"1/2","trace":"1",` +
+				`"minBitLen":0,"maxBitLen":1}` + "\n",
+			200,
+		},
+		{
+			"non-square",
+			[]byte("1,-12345678901234567890,0"),
+			`{"rows":1,"cols":3,"square":false,"symmetric":false,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":null,"nilpotent":null,` +
+				`"magic":false,"latinSquare":false,"density":"2/3","sparsity":"1/3",` +
+				`"minBitLen":0,"maxBitLen":64}` + "\n",
+			200,
+		},
+		{
+			"huge-entries",
+			[]byte(fmt.Sprintf("%s,0\n0,-%s", huge, huge)),
+			`{"rows":2,"cols":2,"square":true,"symmetric":true,"skewSymmetric":false,` +
+				`"diagonal":true,"upperTriangular":true,"lowerTriangular":true,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":null,"nilpotent":null,` +
+				`"magic":false,"latinSquare":false,"density":"1/2","sparsity":"1/2","trace":"0",` +
+				`"minBitLen":0,"maxBitLen":200001}` + "\n",
+			200,
+		},
+		{
+			"growing-powers",
+			[]byte(fmt.Sprintf("%s,1,0,0\n1,-%s,0,0\n0,0,0,0\n0,0,0,0", large, large)),
+			`{"rows":4,"cols":4,"square":true,"symmetric":true,"skewSymmetric":false,` +
+				`"diagonal":false,"upperTriangular":false,"lowerTriangular":false,"identity":false,` +
+				`"permutation":false,"orthogonal":false,"idempotent":false,"nilpotent":null,` +
+				`"magic":false,"latinSquare":false,"density":"1/4","sparsity":"3/4","trace":"0",` +
+				`"minBitLen":0,"maxBitLen":30001}` + "\n",
+			200,
+		},
+		{
+			"non-integer-literals",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleProperties)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runFormFileTestCase(t, h, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
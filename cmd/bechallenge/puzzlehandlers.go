@@ -0,0 +1,550 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	h "net/http"
+	"strconv"
+)
+
+const (
+	// The largest Sudoku the solver takes, and the most guesses it
+	// makes before giving up.
+	maxSudokuSize    = 25
+	maxSudokuGuesses = 1000000
+
+	// The largest generated magic square.
+	maxMagicSize = 1000
+)
+
+// The result of validating a puzzle grid.
+type puzzleCheck struct {
+	// Whether the grid breaks none of the rules.
+	Valid bool `json:"valid"`
+	// Whether the grid has no empty cells.
+	Complete   bool        `json:"complete"`
+	Violations []violation `json:"violations"`
+}
+
+// A rule broken by a puzzle grid: a value out of range, a value
+// repeated in a group of cells, or a line of a magic square with the
+// wrong sum. Index is the number of the row, column or box, if any.
+// Value and Sum are strings so that JSON clients don't lose precision.
+type violation struct {
+	Rule    string `json:"rule"`
+	Index   int    `json:"index,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Sum     string `json:"sum,omitempty"`
+	Cells   []cell `json:"cells"`
+	Message string `json:"message"`
+}
+
+// A cell of a grid, numbered from 1.
+type cell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// A group of cells that a puzzle rule applies to.
+type cellGroup struct {
+	rule  string
+	index int
+	cells []cell
+}
+
+// The result of solving a puzzle. Status is none, unique or multiple;
+// Solution is the first solution found, if any.
+type puzzleSolution struct {
+	Status   string    `json:"status"`
+	Solution [][]int64 `json:"solution"`
+}
+
+// Handles puzzle validation requests by validating the supplied grid
+// of int literals, where 0 stands for an empty cell, and returning the
+// rules of the puzzle set by the `type` query parameter that it breaks
+// as JSON (see puzzleCheck):
+//   - sudoku: the values are 1 to n, and no row, column or box (of
+//     √n x √n cells) repeats one; n must be a perfect square
+//   - latin: the values are 1 to n, and no row or column repeats one
+//   - magic: the values are 1 to n², none repeats, and every complete
+//     row, column and diagonal sums to n(n²+1)/2
+//
+// Expects the matrix CSV in the request context.
+func handlePuzzleValidate(w h.ResponseWriter, r *h.Request) {
+	typ := r.FormValue("type")
+	if typ != "sudoku" && typ != "latin" && typ != "magic" {
+		h.Error(w, "Error: type must be one of sudoku, latin or magic", h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	n := len(m)
+	if typ == "sudoku" && boxSize(n) < 0 {
+		h.Error(w, "Error: the size of a sudoku must be a perfect square", h.StatusBadRequest)
+
+		return
+	}
+
+	writeJSON(w, checkPuzzle(m, typ))
+}
+
+// Checks the square grid `m` against the rules of the puzzle `typ` (see
+// handlePuzzleValidate). The size of a sudoku must be a perfect square.
+func checkPuzzle(m [][]*big.Int, typ string) puzzleCheck {
+	n := len(m)
+	res := puzzleCheck{Complete: true, Violations: []violation{}}
+
+	max := big.NewInt(int64(n))
+	if typ == "magic" {
+		max.Mul(max, max)
+	}
+
+	for i, row := range m {
+		for j, d := range row {
+			if d.Sign() == 0 {
+				res.Complete = false
+			} else if d.Sign() < 0 || d.Cmp(max) > 0 {
+				res.Violations = append(res.Violations, violation{
+					Rule:    "range",
+					Value:   d.String(),
+					Cells:   []cell{{i + 1, j + 1}},
+					Message: fmt.Sprintf("value %s out of range (1-%s)", d, max),
+				})
+			}
+		}
+	}
+
+	for _, g := range puzzleGroups(n, typ) {
+		switch {
+		case typ != "magic":
+			res.Violations = append(res.Violations, duplicates(m, g)...)
+
+		case g.rule == "grid":
+			// Values may not repeat anywhere in a magic square, which
+			// covers its lines.
+			res.Violations = append(res.Violations, duplicates(m, g)...)
+
+		default:
+			if v, ok := magicSum(m, g); !ok {
+				res.Violations = append(res.Violations, v)
+			}
+		}
+	}
+
+	res.Valid = len(res.Violations) == 0
+
+	return res
+}
+
+// Reports whether the square grid `m` is a complete solution of the
+// puzzle `typ`: it has no empty cells and breaks none of the rules.
+func solvesPuzzle(m [][]*big.Int, typ string) bool {
+	res := checkPuzzle(m, typ)
+
+	return res.Valid && res.Complete
+}
+
+// Handles puzzle solving requests by validating the supplied Sudoku
+// grid of int literals, where 0 stands for an empty cell, and
+// returning whether it has no solution, a unique one or multiple ones,
+// along with the first one found, as JSON (see puzzleSolution). The
+// `type` query parameter must be sudoku. Expects the matrix CSV in the
+// request context.
+func handlePuzzleSolve(w h.ResponseWriter, r *h.Request) {
+	if typ := r.FormValue("type"); typ != "sudoku" {
+		h.Error(w, "Error: type must be sudoku", h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	n := len(m)
+	if boxSize(n) < 0 {
+		h.Error(w, "Error: the size of a sudoku must be a perfect square", h.StatusBadRequest)
+
+		return
+	}
+	if n > maxSudokuSize {
+		h.Error(w, fmt.Sprintf("Error: sudokus are limited to %dx%d", maxSudokuSize, maxSudokuSize),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	grid := make([][]int, n)
+	for i, row := range m {
+		grid[i] = make([]int, n)
+		for j, d := range row {
+			if d.Sign() < 0 || d.Cmp(big.NewInt(int64(n))) > 0 {
+				h.Error(w, fmt.Sprintf("Error: value %s out of range (0-%d) at row %d, col %d", d, n, i+1, j+1),
+					h.StatusBadRequest)
+
+				return
+			}
+			grid[i][j] = int(d.Int64())
+		}
+	}
+
+	sol, count, ok := solveSudoku(grid)
+	if !ok {
+		h.Error(w, "Error: the puzzle is too hard to solve", h.StatusBadRequest)
+
+		return
+	}
+
+	res := puzzleSolution{Status: []string{"none", "unique", "multiple"}[count]}
+	if sol != nil {
+		res.Solution = [][]int64{}
+		for _, row := range sol {
+			r := make([]int64, len(row))
+			for j, v := range row {
+				r[j] = int64(v)
+			}
+			res.Solution = append(res.Solution, r)
+		}
+	}
+
+	writeJSON(w, res)
+}
+
+// Handles puzzle generation requests by returning a magic square of
+// the order set by the `n` query parameter: the numbers 1 to n² laid
+// out so that every row, column and diagonal has the same sum. There's
+// none of order 2. The `type` query parameter must be magic.
+func handlePuzzleGenerate(w h.ResponseWriter, r *h.Request) {
+	if typ := r.FormValue("type"); typ != "magic" {
+		h.Error(w, "Error: type must be magic", h.StatusBadRequest)
+
+		return
+	}
+
+	n, err := strconv.Atoi(r.FormValue("n"))
+	if err != nil || n < 0 || n > maxMagicSize {
+		h.Error(w, fmt.Sprintf("Error: n must be an integer between 0 and %d", maxMagicSize),
+			h.StatusBadRequest)
+
+		return
+	}
+	if n == 2 {
+		h.Error(w, "Error: there are no magic squares of order 2", h.StatusBadRequest)
+
+		return
+	}
+
+	sq := magicSquare(n)
+	streamMatrix(w, n, func(i int) []*big.Int {
+		row := make([]*big.Int, n)
+		for j, v := range sq[i] {
+			row[j] = big.NewInt(int64(v))
+		}
+
+		return row
+	})
+}
+
+// Returns the box size √n of an n x n Sudoku, or -1 if n isn't a
+// perfect square.
+func boxSize(n int) int {
+	for k := 0; k*k <= n; k++ {
+		if k*k == n {
+			return k
+		}
+	}
+
+	return -1
+}
+
+// Returns the groups of cells of an n x n puzzle of type `typ` that
+// may not repeat a value (see handlePuzzleValidate).
+func puzzleGroups(n int, typ string) []cellGroup {
+	var groups []cellGroup
+
+	for i := 0; i < n; i++ {
+		row, col := cellGroup{"row", i + 1, nil}, cellGroup{"column", i + 1, nil}
+		for j := 0; j < n; j++ {
+			row.cells = append(row.cells, cell{i + 1, j + 1})
+			col.cells = append(col.cells, cell{j + 1, i + 1})
+		}
+		groups = append(groups, row, col)
+	}
+
+	switch typ {
+	case "sudoku":
+		k := boxSize(n)
+		for b := 0; b < n; b++ {
+			box := cellGroup{"box", b + 1, nil}
+			for i := 0; i < k; i++ {
+				for j := 0; j < k; j++ {
+					box.cells = append(box.cells, cell{b/k*k + i + 1, b%k*k + j + 1})
+				}
+			}
+			groups = append(groups, box)
+		}
+
+	case "magic":
+		diag, anti := cellGroup{"diagonal", 0, nil}, cellGroup{"antidiagonal", 0, nil}
+		grid := cellGroup{"grid", 0, nil}
+		for i := 0; i < n; i++ {
+			diag.cells = append(diag.cells, cell{i + 1, i + 1})
+			anti.cells = append(anti.cells, cell{i + 1, n - i})
+			for j := 0; j < n; j++ {
+				grid.cells = append(grid.cells, cell{i + 1, j + 1})
+			}
+		}
+		groups = append(groups, diag, anti, grid)
+	}
+
+	return groups
+}
+
+// Returns the violations for the non-zero values repeated in the group
+// `g` of cells of `m`, in order of their first occurrence.
+func duplicates(m [][]*big.Int, g cellGroup) []violation {
+	where := make(map[string][]cell)
+	var order []string
+
+	for _, c := range g.cells {
+		d := m[c.Row-1][c.Col-1]
+		if d.Sign() == 0 {
+			continue
+		}
+
+		s := d.String()
+		if where[s] == nil {
+			order = append(order, s)
+		}
+		where[s] = append(where[s], c)
+	}
+
+	var vs []violation
+	for _, s := range order {
+		if len(where[s]) < 2 {
+			continue
+		}
+
+		c := where[s][0]
+		msg := fmt.Sprintf("value %s repeated in %s", s, g.rule)
+		if g.index > 0 {
+			msg += " " + strconv.Itoa(g.index)
+		}
+
+		vs = append(vs, violation{
+			Rule:    g.rule,
+			Index:   g.index,
+			Value:   m[c.Row-1][c.Col-1].String(),
+			Cells:   where[s],
+			Message: msg,
+		})
+	}
+
+	return vs
+}
+
+// Checks that the line `g` of the magic square `m` sums to n(n²+1)/2,
+// unless it has empty cells. Returns false along with the violation if
+// it doesn't.
+func magicSum(m [][]*big.Int, g cellGroup) (violation, bool) {
+	sum := new(big.Int)
+	for _, c := range g.cells {
+		d := m[c.Row-1][c.Col-1]
+		if d.Sign() == 0 {
+			return violation{}, true
+		}
+		sum.Add(sum, d)
+	}
+
+	n := int64(len(m))
+	want := big.NewInt(n * (n*n + 1) / 2)
+	if sum.Cmp(want) == 0 {
+		return violation{}, true
+	}
+
+	name := g.rule
+	if g.index > 0 {
+		name += " " + strconv.Itoa(g.index)
+	}
+
+	return violation{
+		Rule:    g.rule,
+		Index:   g.index,
+		Sum:     sum.String(),
+		Cells:   g.cells,
+		Message: fmt.Sprintf("%s sums to %s instead of %s", name, sum, want),
+	}, false
+}
+
+// Solves the Sudoku `grid` (0 for empty cells) by backtracking, filling
+// the cell with the fewest candidates first. Returns the first solution
+// and the number of solutions, stopping at 2. Returns false if the
+// search takes more than maxSudokuGuesses guesses.
+func solveSudoku(grid [][]int) (solution [][]int, count int, ok bool) {
+	n, k := len(grid), boxSize(len(grid))
+
+	// The values used in each row, column and box, as bit sets.
+	rows, cols, boxes := make([]uint64, n), make([]uint64, n), make([]uint64, n)
+	box := func(i, j int) int { return i/k*k + j/k }
+
+	for i, row := range grid {
+		for j, v := range row {
+			if v == 0 {
+				continue
+			}
+
+			bit := uint64(1) << v
+			if rows[i]&bit != 0 || cols[j]&bit != 0 || boxes[box(i, j)]&bit != 0 {
+				// The givens break the rules.
+				return nil, 0, true
+			}
+			rows[i] |= bit
+			cols[j] |= bit
+			boxes[box(i, j)] |= bit
+		}
+	}
+
+	all := (uint64(1)<<(n+1) - 1) &^ 1
+	guesses := 0
+
+	var search func() bool
+	search = func() bool {
+		// Find the empty cell with the fewest candidates.
+		bi, bj, best := -1, -1, uint64(0)
+		for i, row := range grid {
+			for j, v := range row {
+				if v != 0 {
+					continue
+				}
+
+				c := all &^ (rows[i] | cols[j] | boxes[box(i, j)])
+				if bi < 0 || bits.OnesCount64(c) < bits.OnesCount64(best) {
+					bi, bj, best = i, j, c
+				}
+			}
+		}
+
+		if bi < 0 {
+			count++
+			if solution == nil {
+				solution = make([][]int, n)
+				for i, row := range grid {
+					solution[i] = append([]int(nil), row...)
+				}
+			}
+
+			return count < 2
+		}
+
+		for c := best; c != 0; c &= c - 1 {
+			if guesses++; guesses > maxSudokuGuesses {
+				return false
+			}
+
+			v := bits.TrailingZeros64(c)
+			bit := uint64(1) << v
+
+			grid[bi][bj] = v
+			rows[bi] |= bit
+			cols[bj] |= bit
+			boxes[box(bi, bj)] |= bit
+
+			more := search()
+
+			grid[bi][bj] = 0
+			rows[bi] &^= bit
+			cols[bj] &^= bit
+			boxes[box(bi, bj)] &^= bit
+
+			if !more {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	search()
+
+	return solution, count, guesses <= maxSudokuGuesses
+}
+
+// Returns a magic square of order n (not 2): with the Siamese method
+// for odd n, by complementing the entries on the diagonals of the 4 x 4
+// blocks for doubly even n, and with Strachey's method for singly even
+// n.
+func magicSquare(n int) [][]int {
+	sq := make([][]int, n)
+	for i := range sq {
+		sq[i] = make([]int, n)
+	}
+
+	switch {
+	case n%2 == 1:
+		// Move up and to the right, wrapping around, or down if the
+		// cell is taken.
+		i, j := 0, n/2
+		for v := 1; v <= n*n; v++ {
+			sq[i][j] = v
+			if ni, nj := (i-1+n)%n, (j+1)%n; sq[ni][nj] == 0 {
+				i, j = ni, nj
+			} else {
+				i = (i + 1) % n
+			}
+		}
+
+	case n%4 == 0:
+		for i := range sq {
+			for j := range sq[i] {
+				v := i*n + j + 1
+				if i%4 == j%4 || i%4+j%4 == 3 {
+					v = n*n + 1 - v
+				}
+				sq[i][j] = v
+			}
+		}
+
+	default:
+		// Fill the quadrants with odd magic squares of order m offset
+		// by 0 (top left), m² (bottom right), 2m² (top right) and 3m²
+		// (bottom left), then swap columns between the left and the
+		// right quadrants to even out the sums.
+		m := n / 2
+		a := magicSquare(m)
+		for i := 0; i < m; i++ {
+			for j := 0; j < m; j++ {
+				sq[i][j] = a[i][j]
+				sq[i+m][j+m] = a[i][j] + m*m
+				sq[i][j+m] = a[i][j] + 2*m*m
+				sq[i+m][j] = a[i][j] + 3*m*m
+			}
+		}
+
+		k := (n - 2) / 4
+		for i := 0; i < m; i++ {
+			// The leftmost k columns, shifted right by one in the
+			// middle row.
+			for j := 0; j < k; j++ {
+				c := j
+				if i == m/2 {
+					c++
+				}
+				sq[i][c], sq[i+m][c] = sq[i+m][c], sq[i][c]
+			}
+
+			// The rightmost k-1 columns.
+			for j := n - k + 1; j < n; j++ {
+				sq[i][j], sq[i+m][j] = sq[i+m][j], sq[i][j]
+			}
+		}
+	}
+
+	return sq
+}
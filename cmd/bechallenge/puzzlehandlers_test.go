@@ -0,0 +1,238 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHandlePuzzleValidate(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"sudoku-valid-incomplete",
+			"type=sudoku",
+			[]byte("1,0,0,0\n0,0,3,0\n0,4,0,0\n0,0,0,2"),
+			`{"valid":true,"complete":false,"violations":[]}` + "\n",
+			200,
+		},
+		{
+			"sudoku-solved",
+			"type=sudoku",
+			[]byte("1,2,3,4\n3,4,1,2\n2,1,4,3\n4,3,2,1"),
+			`{"valid":true,"complete":true,"violations":[]}` + "\n",
+			200,
+		},
+		{
+			"sudoku-violations",
+			"type=sudoku",
+			[]byte("1,0,0,1\n0,1,0,0\n0,0,5,0\n0,0,0,0"),
+			`{"valid":false,"complete":false,"violations":[` +
+				`{"rule":"range","value":"5","cells":[{"row":3,"col":3}],"message":"value 5 out of range (1-4)"},` +
+				`{"rule":"row","index":1,"value":"1","cells":[{"row":1,"col":1},{"row":1,"col":4}],` +
+				`"message":"value 1 repeated in row 1"},` +
+				`{"rule":"box","index":1,"value":"1","cells":[{"row":1,"col":1},{"row":2,"col":2}],` +
+				`"message":"value 1 repeated in box 1"}]}` + "\n",
+			200,
+		},
+		{
+			"sudoku-not-perfect-square",
+			"type=sudoku",
+			[]byte("1,2,3\n2,3,1\n3,1,2"),
+			"Error: the size of a sudoku must be a perfect square\n",
+			400,
+		},
+		{
+			"latin",
+			"type=latin",
+			[]byte("1,2,3\n2,3,1\n3,1,2"),
+			`{"valid":true,"complete":true,"violations":[]}` + "\n",
+			200,
+		},
+		{
+			"latin-column",
+			"type=latin",
+			[]byte("1,2,0\n1,0,2\n0,0,0"),
+			`{"valid":false,"complete":false,"violations":[` +
+				`{"rule":"column","index":1,"value":"1","cells":[{"row":1,"col":1},{"row":2,"col":1}],` +
+				`"message":"value 1 repeated in column 1"}]}` + "\n",
+			200,
+		},
+		{
+			"magic",
+			"type=magic",
+			[]byte("2,7,6\n9,5,1\n4,3,8"),
+			`{"valid":true,"complete":true,"violations":[]}` + "\n",
+			200,
+		},
+		{
+			"magic-sums",
+			"type=magic",
+			[]byte("1,2,3\n4,5,6\n7,8,0"),
+			`{"valid":false,"complete":false,"violations":[` +
+				`{"rule":"row","index":1,"sum":"6","cells":[{"row":1,"col":1},{"row":1,"col":2},{"row":1,"col":3}],` +
+				`"message":"row 1 sums to 6 instead of 15"},` +
+				`{"rule":"column","index":1,"sum":"12","cells":[{"row":1,"col":1},{"row":2,"col":1},{"row":3,"col":1}],` +
+				`"message":"column 1 sums to 12 instead of 15"}]}` + "\n",
+			200,
+		},
+		{
+			"magic-repeated",
+			"type=magic",
+			[]byte("5,5,5\n5,5,5\n5,5,5"),
+			`{"valid":false,"complete":true,"violations":[{"rule":"grid","value":"5","cells":[` +
+				`{"row":1,"col":1},{"row":1,"col":2},{"row":1,"col":3},{"row":2,"col":1},{"row":2,"col":2},` +
+				`{"row":2,"col":3},{"row":3,"col":1},{"row":3,"col":2},{"row":3,"col":3}],` +
+				`"message":"value 5 repeated in grid"}]}` + "\n",
+			200,
+		},
+		{
+			"unknown-type",
+			"type=kakuro",
+			[]byte("1"),
+			"Error: type must be one of sudoku, latin or magic\n",
+			400,
+		},
+	}
+
+	h := webApiMiddleware(handlePuzzleValidate)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandlePuzzleSolve(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"unique",
+			"type=sudoku",
+			[]byte("1,0,0,0\n0,0,3,0\n0,4,0,0\n0,0,0,2"),
+			`{"status":"unique","solution":[[1,3,2,4],[4,2,3,1],[2,4,1,3],[3,1,4,2]]}` + "\n",
+			200,
+		},
+		{
+			"multiple",
+			"type=sudoku",
+			[]byte("0,0,0,0\n0,0,0,0\n0,0,0,0\n0,0,0,0"),
+			`{"status":"multiple","solution":[[1,2,3,4],[3,4,1,2],[2,1,4,3],[4,3,2,1]]}` + "\n",
+			200,
+		},
+		{
+			"none",
+			"type=sudoku",
+			[]byte("0,2,3,0\n0,1,0,0\n4,0,0,0\n0,0,0,0"),
+			`{"status":"none","solution":null}` + "\n",
+			200,
+		},
+		{
+			"conflicting-givens",
+			"type=sudoku",
+			[]byte("1,1,0,0\n0,0,0,0\n0,0,0,0\n0,0,0,0"),
+			`{"status":"none","solution":null}` + "\n",
+			200,
+		},
+		{
+			"out-of-range",
+			"type=sudoku",
+			[]byte("1,0,0,0\n0,0,0,0\n0,0,9,0\n0,0,0,0"),
+			"Error: value 9 out of range (0-4) at row 3, col 3\n",
+			400,
+		},
+		{
+			"unknown-type",
+			"type=latin",
+			[]byte("1"),
+			"Error: type must be sudoku\n",
+			400,
+		},
+	}
+
+	h := webApiMiddleware(handlePuzzleSolve)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandlePuzzleGenerate(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"odd",
+			"type=magic&n=3",
+			nil,
+			"8,1,6\n3,5,7\n4,9,2\n",
+			200,
+		},
+		{
+			"doubly-even",
+			"type=magic&n=4",
+			nil,
+			"16,2,3,13\n5,11,10,8\n9,7,6,12\n4,14,15,1\n",
+			200,
+		},
+		{
+			"one",
+			"type=magic&n=1",
+			nil,
+			"1\n",
+			200,
+		},
+		{
+			"two",
+			"type=magic&n=2",
+			nil,
+			"Error: there are no magic squares of order 2\n",
+			400,
+		},
+		{
+			"invalid-n",
+			"type=magic&n=1001",
+			nil,
+			"Error: n must be an integer between 0 and 1000\n",
+			400,
+		},
+		{
+			"unknown-type",
+			"type=sudoku&n=9",
+			nil,
+			"Error: type must be magic\n",
+			400,
+		},
+	}
+
+	h := queryApiMiddleware(handlePuzzleGenerate)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestMagicSquare(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		if n == 2 {
+			continue
+		}
+
+		seen := make([]bool, n*n+1)
+		m := make([][]*big.Int, n)
+		for i, row := range magicSquare(n) {
+			m[i] = make([]*big.Int, n)
+			for j, v := range row {
+				if v < 1 || v > n*n || seen[v] {
+					t.Fatalf("n=%d: value %d out of range or repeated", n, v)
+				}
+				seen[v] = true
+				m[i][j] = big.NewInt(int64(v))
+			}
+		}
+
+		if !solvesPuzzle(m, "magic") {
+			t.Errorf("n=%d: %v is not magic", n, m)
+		}
+	}
+}
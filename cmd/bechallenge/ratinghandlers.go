@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/big"
+	h "net/http"
+	"slices"
+	"strconv"
+)
+
+const (
+	// The starting Elo rating and the default K-factor.
+	eloInitial = 1500
+	eloK       = 20
+
+	// Caps the number of Bradley-Terry iterations; they converge
+	// linearly, or not at all if a team won or lost every match.
+	maxBradleyTerryIterations = 10000
+
+	// Caps the significant digits of the Bradley-Terry strengths; as
+	// the iterations converge linearly, each digit takes about as many
+	// of them.
+	maxBradleyTerryPrec = 30
+
+	// Caps the number of teams rated with the methods other than Elo,
+	// which solve linear systems or iterate over all the pairs of
+	// teams.
+	maxRatingTeams = 100
+)
+
+// A row of the ratings table.
+type rating struct {
+	Rank   int    `json:"rank"`
+	Team   string `json:"team"`
+	Rating string `json:"rating"`
+}
+
+// Handles ratings requests by validating the supplied results matrix
+// of int literals (see handleStandings) and returning the teams ranked
+// by the strength rating selected with the `method` query parameter:
+//   - massey: least squares fit of the rating differences to the goal
+//     differences, with the ratings summing to 0
+//   - colley: Colley's bias-free win percentages
+//   - bradley-terry: maximum likelihood strengths, summing to 1
+//   - elo: Elo ratings after playing the matches in matrix order (row
+//     by row, above the diagonal) from 1500 with the K-factor set by
+//     the `k` query parameter (20 by default), to 2 decimals
+//
+// Draws count as half a win and half a loss. The Massey and Colley
+// linear systems are solved exactly, and the ratings are fractions
+// unless the `prec` query parameter asks for decimals. Bradley-Terry
+// iterates at `prec` significant digits (20 by default, and at most
+// maxBradleyTerryPrec) until the ratings stop changing, and fails if
+// they don't within maxBradleyTerryIterations iterations. All the
+// methods but Elo are limited to maxRatingTeams teams. The `header` and
+// `format` query parameters work as for handleStandings. Expects the
+// matrix CSV in the request context.
+func handleRatings(w h.ResponseWriter, r *h.Request) {
+	method := r.FormValue("method")
+	switch method {
+	case "massey", "colley", "bradley-terry", "elo":
+	case "":
+		h.Error(w, "Error: method expected", h.StatusBadRequest)
+
+		return
+	default:
+		h.Error(w, fmt.Sprintf("Error: unknown method %q", method), h.StatusBadRequest)
+
+		return
+	}
+
+	var prec int
+	var err error
+	if method == "bradley-terry" {
+		if prec, err = parsePrec(r); err != nil || prec > maxBradleyTerryPrec {
+			err = fmt.Errorf("prec must be an integer between 1 and %d", maxBradleyTerryPrec)
+		}
+	} else {
+		prec, err = parseOptPrec(r)
+	}
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	k := float64(eloK)
+	if s := r.FormValue("k"); s != "" {
+		if k, err = strconv.ParseFloat(s, 64); err != nil || !(k > 0) || math.IsInf(k, 0) {
+			h.Error(w, "Error: k must be a positive number", h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	format := r.FormValue("format")
+	if format != "" && format != "csv" && format != "json" {
+		h.Error(w, fmt.Sprintf("Error: unknown format %q", format), h.StatusBadRequest)
+
+		return
+	}
+
+	labels, m, ok := parseLabeledMatrix(w, r)
+	if !ok {
+		return
+	}
+	if method != "elo" && len(m) > maxRatingTeams {
+		h.Error(w, fmt.Sprintf("Error: %s ratings are limited to %d teams", method, maxRatingTeams),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	var values []string
+	var cmp func(i, j int) int
+
+	switch method {
+	case "massey", "colley":
+		var x []*big.Rat
+		if method == "massey" {
+			x = massey(m)
+		} else {
+			x = colley(m)
+		}
+
+		for _, v := range x {
+			values = append(values, rtos(v, prec))
+		}
+		cmp = func(i, j int) int { return x[i].Cmp(x[j]) }
+
+	case "bradley-terry":
+		x, ok := bradleyTerry(m, prec)
+		if !ok {
+			h.Error(w, fmt.Sprintf("Error: no convergence after %d iterations", maxBradleyTerryIterations),
+				h.StatusBadRequest)
+
+			return
+		}
+
+		for _, v := range x {
+			values = append(values, ftos(v, prec))
+		}
+		cmp = func(i, j int) int { return x[i].Cmp(x[j]) }
+
+	case "elo":
+		x := elo(m, k)
+
+		for _, v := range x {
+			values = append(values, strconv.FormatFloat(v, 'f', 2, 64))
+		}
+		cmp = func(i, j int) int { return compareFloat(x[i], x[j]) }
+	}
+
+	table := rankRatings(labels, values, cmp)
+
+	if format == "json" {
+		writeJSON(w, table)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "team", "rating"})
+	for _, rt := range table {
+		cw.Write([]string{fmt.Sprint(rt.Rank), rt.Team, rt.Rating})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		l.Error("writing CSV response", "err", err)
+	}
+}
+
+// Orders the teams by descending rating, keeping the matrix order
+// among equal ones, which share a rank.
+func rankRatings(labels, values []string, cmp func(i, j int) int) []rating {
+	order := make([]int, len(labels))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int { return cmp(j, i) })
+
+	table := []rating{}
+	for k, i := range order {
+		rank := k + 1
+		if k > 0 && cmp(i, order[k-1]) == 0 {
+			rank = table[k-1].Rank
+		}
+
+		table = append(table, rating{rank, labels[i], values[i]})
+	}
+
+	return table
+}
+
+// Returns -1, 0 or 1 as `x` is less than, equal to or greater than `y`.
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
+}
+
+// Returns the Massey ratings for the results matrix `m`: the least
+// squares solution of rᵢ - rⱼ = goal difference of i against j over
+// all the matches, normalized to sum to 0.
+func massey(m [][]*big.Int) []*big.Rat {
+	n := len(m)
+	if n == 0 {
+		return nil
+	}
+
+	// The normal equations: the Massey matrix has the number of matches
+	// on the diagonal and minus the number between i and j elsewhere,
+	// and the right-hand side is the total goal difference. It's
+	// singular, so the last equation is replaced with the
+	// normalization.
+	a, b := newRatMatrix(n, n), newRatMatrix(n, 1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+
+			a[i][i].Add(a[i][i], big.NewRat(1, 1))
+			a[i][j].SetInt64(-1)
+
+			d := new(big.Int).Sub(m[i][j], m[j][i])
+			b[i][0].Add(b[i][0], new(big.Rat).SetInt(d))
+		}
+	}
+
+	for j := range a[n-1] {
+		a[n-1][j].SetInt64(1)
+	}
+	b[n-1][0].SetInt64(0)
+
+	return solveColumn(a, b)
+}
+
+// Returns the Colley ratings for the results matrix `m`, with draws as
+// half a win and half a loss.
+func colley(m [][]*big.Int) []*big.Rat {
+	n := len(m)
+
+	// The Colley matrix is 2 plus the number of matches on the
+	// diagonal and minus the number between i and j elsewhere, and the
+	// right-hand side is 1 + (wins - losses)/2.
+	a, b := newRatMatrix(n, n), newRatMatrix(n, 1)
+	for i := 0; i < n; i++ {
+		a[i][i].SetInt64(2)
+		b[i][0].SetInt64(1)
+
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+
+			a[i][i].Add(a[i][i], big.NewRat(1, 1))
+			a[i][j].SetInt64(-1)
+
+			half := big.NewRat(int64(m[i][j].Cmp(m[j][i])), 2)
+			b[i][0].Add(b[i][0], half)
+		}
+	}
+
+	return solveColumn(a, b)
+}
+
+// Solves a·x = b for the column vector `b`. The matrix `a` must be
+// nonsingular.
+func solveColumn(a, b [][]*big.Rat) []*big.Rat {
+	x, err := solveRat(a, b)
+	if err != nil {
+		panic(err)
+	}
+
+	out := make([]*big.Rat, len(x))
+	for i, row := range x {
+		out[i] = row[0]
+	}
+
+	return out
+}
+
+// Returns the Bradley-Terry strengths for the results matrix `m`,
+// computed with the MM algorithm at `prec` significant digits:
+//
+//	pᵢ ← Wᵢ / Σⱼ 1/(pᵢ + pⱼ)
+//
+// where Wᵢ is the number of wins of team i, normalizing the strengths
+// to sum to 1 after each step. Returns false if they're still changing
+// after maxBradleyTerryIterations steps.
+func bradleyTerry(m [][]*big.Int, prec int) ([]*big.Float, bool) {
+	n := len(m)
+	bits := precBits(prec)
+
+	// Twice the wins, so that draws are whole numbers.
+	wins := make([]*big.Float, n)
+	for i := range m {
+		var w2 int64
+		for j := range m {
+			if i != j {
+				w2 += int64(m[i][j].Cmp(m[j][i]) + 1)
+			}
+		}
+		wins[i] = newFloat(bits, float64(w2)/2)
+	}
+
+	one := newFloat(bits, 1)
+	tol := new(big.Float).SetMantExp(one, -int(bits-8))
+
+	p := make([]*big.Float, n)
+	for i := range p {
+		p[i] = newFloat(bits, 0).Quo(one, newFloat(bits, float64(n)))
+	}
+
+	for it := 0; it < maxBradleyTerryIterations; it++ {
+		next := make([]*big.Float, n)
+		sum := newFloat(bits, 0)
+
+		for i := range p {
+			next[i] = newFloat(bits, 0)
+			if wins[i].Sign() == 0 {
+				continue
+			}
+
+			denom := newFloat(bits, 0)
+			for j := range p {
+				if i != j {
+					t := newFloat(bits, 0).Add(p[i], p[j])
+					denom.Add(denom, t.Quo(one, t))
+				}
+			}
+
+			next[i].Quo(wins[i], denom)
+			sum.Add(sum, next[i])
+		}
+
+		// Stop once no strength changes by more than the precision.
+		done := true
+		for i := range next {
+			if sum.Sign() != 0 {
+				next[i].Quo(next[i], sum)
+			}
+
+			d := newFloat(bits, 0).Sub(next[i], p[i])
+			if d.Abs(d).Cmp(tol) > 0 {
+				done = false
+			}
+		}
+
+		p = next
+		if done {
+			return p, true
+		}
+	}
+
+	return p, false
+}
+
+// Returns the Elo ratings for the results matrix `m` with the K-factor
+// `k` (see handleRatings).
+func elo(m [][]*big.Int, k float64) []float64 {
+	r := make([]float64, len(m))
+	for i := range r {
+		r[i] = eloInitial
+	}
+
+	for i := range m {
+		for j := i + 1; j < len(m); j++ {
+			score := float64(m[i][j].Cmp(m[j][i])+1) / 2
+			expected := 1 / (1 + math.Pow(10, (r[j]-r[i])/400))
+
+			r[i] += k * (score - expected)
+			r[j] -= k * (score - expected)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleRatings(t *testing.T) {
+	// A beats B 2-0 and C 1-0, B beats C 3-1.
+	results := []byte("A,B,C\n0,2,1\n0,0,3\n0,1,0")
+	// A beats B, B beats C, A and C draw.
+	cycle := []byte("0,1,1\n0,0,1\n1,0,0")
+	// A wins every match, so its Bradley-Terry strength tends to 1.
+	dominant := []byte("0,1,1\n0,0,1\n0,0,0")
+	// More teams than the linear and iterative methods take.
+	row := strings.TrimSuffix(strings.Repeat("0,", maxRatingTeams+1), ",")
+	large := []byte(strings.TrimSuffix(strings.Repeat(row+"\n", maxRatingTeams+1), "\n"))
+
+	tests := []queryTestCase{
+		{
+			"massey",
+			"method=massey&header=true",
+			results,
+			"rank,team,rating\n1,A,1\n2,B,0\n3,C,-1\n",
+			200,
+		},
+		{
+			"colley",
+			"method=colley&header=true",
+			results,
+			"rank,team,rating\n1,A,7/10\n2,B,1/2\n3,C,3/10\n",
+			200,
+		},
+		{
+			"colley-prec",
+			"method=colley&prec=3&format=json",
+			cycle,
+			`[{"rank":1,"team":"1","rating":"0.6"},{"rank":2,"team":"2","rating":"0.5"},` +
+				`{"rank":3,"team":"3","rating":"0.4"}]` + "\n",
+			200,
+		},
+		{
+			"bradley-terry",
+			"method=bradley-terry&prec=10",
+			cycle,
+			"rank,team,rating\n1,1,0.5918107263\n2,2,0.2777938389\n3,3,0.1303954348\n",
+			200,
+		},
+		{
+			"elo",
+			"method=elo&header=true",
+			results,
+			"rank,team,rating\n1,A,1519.71\n2,B,1500.01\n3,C,1480.28\n",
+			200,
+		},
+		{
+			"elo-k",
+			"method=elo&k=32",
+			cycle,
+			"rank,team,rating\n1,1,1515.26\n2,2,1500.77\n3,3,1483.97\n",
+			200,
+		},
+		{
+			"shared-rank",
+			"method=massey",
+			[]byte("0,1\n1,0"),
+			"rank,team,rating\n1,1,0\n1,2,0\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"method=colley&format=json",
+			[]byte{},
+			"[]\n",
+			200,
+		},
+		{
+			"missing-method",
+			"",
+			results,
+			"Error: method expected\n",
+			400,
+		},
+		{
+			"unknown-method",
+			"method=glicko",
+			results,
+			"Error: unknown method \"glicko\"\n",
+			400,
+		},
+		{
+			"invalid-k",
+			"method=elo&k=-1",
+			results,
+			"Error: k must be a positive number\n",
+			400,
+		},
+		{
+			"bradley-terry-no-convergence",
+			"method=bradley-terry",
+			dominant,
+			"Error: no convergence after 10000 iterations\n",
+			400,
+		},
+		{
+			"too-many-teams",
+			"method=colley",
+			large,
+			"Error: colley ratings are limited to 100 teams\n",
+			400,
+		},
+		{
+			"bradley-terry-invalid-prec",
+			"method=bradley-terry&prec=0",
+			results,
+			"Error: prec must be an integer between 1 and 30\n",
+			400,
+		},
+		{
+			"bradley-terry-prec-too-high",
+			"method=bradley-terry&prec=31",
+			results,
+			"Error: prec must be an integer between 1 and 30\n",
+			400,
+		},
+		{
+			"invalid-prec",
+			"method=massey&prec=1001",
+			results,
+			"Error: prec must be an integer between 1 and 1000\n",
+			400,
+		},
+		{
+			"not-square",
+			"method=massey",
+			results,
+			"Error: matrix is not square\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleRatings)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
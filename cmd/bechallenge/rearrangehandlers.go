@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+)
+
+// Handles rotate requests by validating the supplied matrix of int
+// literals and returning it rotated clockwise by the `deg` query
+// parameter (90, 180 or 270) degrees. Expects the matrix CSV in the
+// request context.
+func handleRotate(w h.ResponseWriter, r *h.Request) {
+	var fn func([][]*big.Int) [][]*big.Int
+
+	switch deg := r.FormValue("deg"); deg {
+	case "90":
+		fn = rotate90
+	case "180":
+		fn = func(m [][]*big.Int) [][]*big.Int { return rotate90(rotate90(m)) }
+	case "270", "-90":
+		fn = func(m [][]*big.Int) [][]*big.Int { return rotate90(rotate90(rotate90(m))) }
+	default:
+		h.Error(w, "Error: deg must be one of 90, 180 or 270", h.StatusBadRequest)
+
+		return
+	}
+
+	rearrange(w, r, fn)
+}
+
+// Handles flip requests by validating the supplied matrix of int
+// literals and returning its mirror image: left to right for `axis=h`,
+// upside down for `axis=v`. Expects the matrix CSV in the request
+// context.
+func handleFlip(w h.ResponseWriter, r *h.Request) {
+	var fn func([][]*big.Int) [][]*big.Int
+
+	switch axis := r.FormValue("axis"); axis {
+	case "h":
+		fn = flipH
+	case "v":
+		fn = flipV
+	default:
+		h.Error(w, "Error: axis must be one of h or v", h.StatusBadRequest)
+
+		return
+	}
+
+	rearrange(w, r, fn)
+}
+
+// Handles antitranspose requests by validating the supplied matrix of
+// int literals and returning its reflection over the anti-diagonal.
+// Expects the matrix CSV in the request context.
+func handleAntitranspose(w h.ResponseWriter, r *h.Request) {
+	rearrange(w, r, func(m [][]*big.Int) [][]*big.Int {
+		// Rotating the transpose by 180 degrees.
+		return flipV(flipH(transpose(m)))
+	})
+}
+
+// Handles spiral requests by validating the supplied matrix of int
+// literals and returning a one line string with its elements in
+// clockwise spiral order starting from the top left corner. Expects the
+// matrix CSV in the request context.
+func handleSpiral(w h.ResponseWriter, r *h.Request) {
+	traverse(w, r, spiral)
+}
+
+// Handles zigzag requests by validating the supplied matrix of int
+// literals and returning a one line string with its elements in zigzag
+// (JPEG) order: along the anti-diagonals starting from the top left
+// corner, alternating direction. Expects the matrix CSV in the request
+// context.
+func handleZigzag(w h.ResponseWriter, r *h.Request) {
+	traverse(w, r, zigzag)
+}
+
+// Handles diagonals requests by validating the supplied matrix of int
+// literals and returning a one line string with its elements in
+// diagonal order: each of the diagonals parallel to the main one read
+// top to bottom, starting from the top right corner and ending at the
+// bottom left one. Expects the matrix CSV in the request context.
+func handleDiagonals(w h.ResponseWriter, r *h.Request) {
+	traverse(w, r, diagonals)
+}
+
+// Implements the actual handler for rearrangement requests: returns
+// the matrix produced by `fn` in the format of the echo handler.
+func rearrange(w h.ResponseWriter, r *h.Request, fn func([][]*big.Int) [][]*big.Int) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	fmt.Fprint(w, mtos(fn(m)))
+}
+
+// Implements the actual handler for traversal requests: returns the
+// sequence of elements produced by `fn` in the format of the flatten
+// handler.
+func traverse(w h.ResponseWriter, r *h.Request, fn func([][]*big.Int) []*big.Int) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, itos(fn(m)), "\n")
+}
+
+// Returns the transpose of the matrix `m`.
+func transpose(m [][]*big.Int) [][]*big.Int {
+	out := make([][]*big.Int, ncols(m))
+
+	for j := range out {
+		out[j] = make([]*big.Int, len(m))
+		for i := range m {
+			out[j][i] = m[i][j]
+		}
+	}
+
+	return out
+}
+
+// Returns the matrix `m` rotated clockwise by 90 degrees.
+func rotate90(m [][]*big.Int) [][]*big.Int {
+	return flipH(transpose(m))
+}
+
+// Returns the matrix `m` with the order of the columns reversed.
+func flipH(m [][]*big.Int) [][]*big.Int {
+	out := make([][]*big.Int, len(m))
+
+	for i, row := range m {
+		out[i] = make([]*big.Int, len(row))
+		for j, d := range row {
+			out[i][len(row)-1-j] = d
+		}
+	}
+
+	return out
+}
+
+// Returns the matrix `m` with the order of the rows reversed.
+func flipV(m [][]*big.Int) [][]*big.Int {
+	out := make([][]*big.Int, len(m))
+
+	for i, row := range m {
+		out[len(m)-1-i] = row
+	}
+
+	return out
+}
+
+// Returns the elements of the matrix `m` in clockwise spiral order.
+func spiral(m [][]*big.Int) []*big.Int {
+	var out []*big.Int
+	top, bottom, left, right := 0, len(m)-1, 0, ncols(m)-1
+
+	for top <= bottom && left <= right {
+		for j := left; j <= right; j++ {
+			out = append(out, m[top][j])
+		}
+		for i := top + 1; i <= bottom; i++ {
+			out = append(out, m[i][right])
+		}
+		if top < bottom {
+			for j := right - 1; j >= left; j-- {
+				out = append(out, m[bottom][j])
+			}
+		}
+		if left < right {
+			for i := bottom - 1; i > top; i-- {
+				out = append(out, m[i][left])
+			}
+		}
+
+		top, bottom, left, right = top+1, bottom-1, left+1, right-1
+	}
+
+	return out
+}
+
+// Returns the elements of the matrix `m` in zigzag order.
+func zigzag(m [][]*big.Int) []*big.Int {
+	var out []*big.Int
+	rows, cols := len(m), ncols(m)
+
+	// Walk the anti-diagonals i+j = s, going up on even ones and down
+	// on odd ones.
+	for s := 0; s < rows+cols-1; s++ {
+		lo, hi := max(0, s-cols+1), min(s, rows-1)
+
+		if s%2 == 0 {
+			for i := hi; i >= lo; i-- {
+				out = append(out, m[i][s-i])
+			}
+		} else {
+			for i := lo; i <= hi; i++ {
+				out = append(out, m[i][s-i])
+			}
+		}
+	}
+
+	return out
+}
+
+// Returns the elements of the matrix `m` in diagonal order.
+func diagonals(m [][]*big.Int) []*big.Int {
+	var out []*big.Int
+	rows, cols := len(m), ncols(m)
+
+	// Walk the diagonals j-i = k from the top right corner down.
+	for k := cols - 1; k > -rows; k-- {
+		for i := max(0, -k); i < rows && i+k < cols; i++ {
+			out = append(out, m[i][i+k])
+		}
+	}
+
+	return out
+}
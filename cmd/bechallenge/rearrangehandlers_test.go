@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRearrangeHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"rotate-90",
+			handleRotate,
+			"deg=90",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"7,4,1\n8,5,2\n9,6,3\n",
+			200,
+		},
+		{
+			"rotate-180",
+			handleRotate,
+			"deg=180",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"9,8,7\n6,5,4\n3,2,1\n",
+			200,
+		},
+		{
+			"rotate-270",
+			handleRotate,
+			"deg=270",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"3,6,9\n2,5,8\n1,4,7\n",
+			200,
+		},
+		{
+			"rotate-non-square",
+			handleRotate,
+			"deg=90",
+			[]byte("1,2,3\n4,5,6"),
+			"4,1\n5,2\n6,3\n",
+			200,
+		},
+		{
+			"rotate-invalid-deg",
+			handleRotate,
+			"deg=45",
+			[]byte("1"),
+			"Error: deg must be one of 90, 180 or 270\n",
+			400,
+		},
+		{
+			"flip-h",
+			handleFlip,
+			"axis=h",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"3,2,1\n6,5,4\n9,8,7\n",
+			200,
+		},
+		{
+			"flip-v",
+			handleFlip,
+			"axis=v",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"7,8,9\n4,5,6\n1,2,3\n",
+			200,
+		},
+		{
+			"flip-invalid-axis",
+			handleFlip,
+			"axis=d",
+			[]byte("1"),
+			"Error: axis must be one of h or v\n",
+			400,
+		},
+		{
+			"antitranspose",
+			handleAntitranspose,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"9,6,3\n8,5,2\n7,4,1\n",
+			200,
+		},
+		{
+			"antitranspose-non-square",
+			handleAntitranspose,
+			"",
+			[]byte("1,2,3\n4,5,6"),
+			"6,3\n5,2\n4,1\n",
+			200,
+		},
+		{
+			"spiral",
+			handleSpiral,
+			"",
+			[]byte("1,2,3,4\n5,6,7,8\n9,10,11,12"),
+			"1,2,3,4,8,12,11,10,9,5,6,7\n",
+			200,
+		},
+		{
+			"spiral-column",
+			handleSpiral,
+			"",
+			[]byte("1\n2\n3"),
+			"1,2,3\n",
+			200,
+		},
+		{
+			"zigzag",
+			handleZigzag,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"1,2,4,7,5,3,6,8,9\n",
+			200,
+		},
+		{
+			"diagonals",
+			handleDiagonals,
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"3,2,6,1,5,9,4,8,7\n",
+			200,
+		},
+		{
+			"large-integers",
+			handleSpiral,
+			"",
+			[]byte("-12345678901234567890,0\n0,12345678901234567890"),
+			"-12345678901234567890,0,12345678901234567890,0\n",
+			200,
+		},
+		{
+			"empty-csv",
+			handleZigzag,
+			"",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"empty-csv-rearrange",
+			handleAntitranspose,
+			"",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"non-integer-literals",
+			handleDiagonals,
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := rectApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
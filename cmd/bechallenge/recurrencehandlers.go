@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	h "net/http"
+	"strings"
+)
+
+const (
+	// The largest index of a term, in bits.
+	maxRecurrenceBits = 4096
+
+	// The largest size of a modulus, and of a term without one, in bits.
+	// The entries of the powers of the companion matrix are as large.
+	maxRecurrenceModBits  = 4096
+	maxRecurrenceTermBits = 1 << 16
+
+	// The most coefficients of a recurrence.
+	maxRecurrenceOrder = 100
+
+	// Caps the number of entry products of the powers of the companion
+	// matrix, about its order cubed times the bit length of the index.
+	maxRecurrenceWork = 1 << 22
+)
+
+// Handles recurrence requests by returning the n-th term aₙ of the
+// linear recurrence
+//
+//	aₖ = c₁·aₖ₋₁ + c₂·aₖ₋₂ + … + c_d·aₖ₋d
+//
+// with the comma separated coefficients c₁, …, c_d in the `coeffs`
+// query parameter and the initial terms a₀, …, a_d₋₁ in `init`.
+//
+// The term is computed from the n-th power of the companion matrix of
+// the recurrence by repeated squaring. With the `mod` query parameter,
+// it's reduced modulo it, which makes huge `n` practical; without it
+// the size of the terms grows linearly with `n`, which limits it. Both
+// `n` and `mod` accept powers like 10^18.
+func handleRecurrence(w h.ResponseWriter, r *h.Request) {
+	coeffs, err := parseInts(r.FormValue("coeffs"))
+	if err != nil || len(coeffs) == 0 || len(coeffs) > maxRecurrenceOrder {
+		h.Error(w, fmt.Sprintf("Error: coeffs must be a list of 1 to %d integers", maxRecurrenceOrder),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	init, err := parseInts(r.FormValue("init"))
+	if err != nil || len(init) != len(coeffs) {
+		h.Error(w, "Error: init must be a list of as many integers as coeffs", h.StatusBadRequest)
+
+		return
+	}
+
+	var mod *big.Int
+	if s := r.FormValue("mod"); s != "" {
+		if mod, err = parsePower(s); err != nil || mod.Sign() <= 0 || mod.BitLen() > maxRecurrenceModBits {
+			h.Error(w, fmt.Sprintf("Error: mod must be a positive integer less than 2^%d", maxRecurrenceModBits),
+				h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	n, err := parsePower(r.FormValue("n"))
+	if err != nil || n.Sign() < 0 || n.BitLen() > maxRecurrenceBits {
+		h.Error(w, fmt.Sprintf("Error: n must be a non-negative integer less than 2^%d", maxRecurrenceBits),
+			h.StatusBadRequest)
+
+		return
+	}
+	if mod == nil && (n.BitLen() > 32 || n.Int64()*termGrowth(coeffs) > maxRecurrenceTermBits) {
+		h.Error(w, "Error: the term is too large; set mod", h.StatusBadRequest)
+
+		return
+	}
+	if d := len(coeffs); d*d*d*n.BitLen() > maxRecurrenceWork {
+		h.Error(w, "Error: the order of the recurrence is too large for n", h.StatusBadRequest)
+
+		return
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprintln(w, recurrenceTerm(coeffs, init, n, mod))
+}
+
+// Parses the comma separated list of int literals `s`.
+func parseInts(s string) ([]*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	return atoi(strings.Split(s, ","))
+}
+
+// Parses the int literal or power of int literals (like 10^18) `s`.
+func parsePower(s string) (*big.Int, error) {
+	base, exp, found := strings.Cut(s, "^")
+	if !found {
+		ds, err := atoi([]string{s})
+		if err != nil {
+			return nil, err
+		}
+
+		return ds[0], nil
+	}
+
+	ds, err := atoi([]string{base, exp})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject the exponents that would make the power too large to be
+	// useful before computing it.
+	b, e := ds[0], ds[1]
+	if e.Sign() < 0 || b.CmpAbs(big.NewInt(1)) > 0 && e.Cmp(big.NewInt(maxRecurrenceBits)) > 0 {
+		return nil, fmt.Errorf("power %q out of range", s)
+	}
+
+	return b.Exp(b, e, nil), nil
+}
+
+// Returns a bound on the number of bits each term of the recurrence
+// with the coefficients `coeffs` adds to the size of the next ones.
+func termGrowth(coeffs []*big.Int) int64 {
+	var bits int
+	for _, c := range coeffs {
+		bits = max(bits, c.BitLen())
+	}
+
+	return int64(bits + big.NewInt(int64(len(coeffs))).BitLen())
+}
+
+// Returns the n-th term of the recurrence with the coefficients
+// `coeffs` and initial terms `init` (see handleRecurrence), modulo
+// `mod` unless it's nil.
+func recurrenceTerm(coeffs, init []*big.Int, n, mod *big.Int) *big.Int {
+	d := len(coeffs)
+
+	reduce := func(x *big.Int) *big.Int {
+		if mod != nil {
+			x.Mod(x, mod)
+		}
+
+		return x
+	}
+
+	if n.Cmp(big.NewInt(int64(d))) < 0 {
+		return reduce(new(big.Int).Set(init[n.Int64()]))
+	}
+
+	// The companion matrix maps (aₖ₊d₋₁, …, aₖ) to (aₖ₊d, …, aₖ₊₁).
+	c := make([][]*big.Int, d)
+	for i := range c {
+		c[i] = make([]*big.Int, d)
+		for j := range c[i] {
+			c[i][j] = new(big.Int)
+		}
+		if i > 0 {
+			c[i][i-1].SetInt64(1)
+		}
+	}
+	for j, x := range coeffs {
+		c[0][j].Set(x)
+	}
+
+	// aₙ is the last entry of Cⁿ·(a_d₋₁, …, a₀).
+	p := powInt(c, n, mod)
+
+	term, t := new(big.Int), new(big.Int)
+	for j := range p[d-1] {
+		term.Add(term, t.Mul(p[d-1][j], init[d-1-j]))
+	}
+
+	return reduce(term)
+}
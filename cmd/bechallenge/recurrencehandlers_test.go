@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleRecurrence(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"fibonacci",
+			"coeffs=1,1&init=0,1&n=10",
+			nil,
+			"55\n",
+			200,
+		},
+		{
+			"fibonacci-initial-term",
+			"coeffs=1,1&init=0,1&n=1",
+			nil,
+			"1\n",
+			200,
+		},
+		{
+			"fibonacci-large",
+			"coeffs=1,1&init=0,1&n=300",
+			nil,
+			"222232244629420445529739893461909967206666939096499764990979600\n",
+			200,
+		},
+		{
+			"fibonacci-mod",
+			"coeffs=1,1&init=0,1&n=10^18&mod=1000000007",
+			nil,
+			"209783453\n",
+			200,
+		},
+		{
+			"mod-matches-exact",
+			"coeffs=1,1&init=0,1&n=300&mod=1000000007",
+			nil,
+			"644264086\n",
+			200,
+		},
+		{
+			"tribonacci",
+			"coeffs=1,1,1&init=0,0,1&n=20",
+			nil,
+			"35890\n",
+			200,
+		},
+		{
+			"negative-coefficients-mod",
+			"coeffs=-1&init=1&n=3&mod=5",
+			nil,
+			"4\n",
+			200,
+		},
+		{
+			"n-zero-mod",
+			"coeffs=2&init=7&n=0&mod=5",
+			nil,
+			"2\n",
+			200,
+		},
+		{
+			"missing-coeffs",
+			"init=0,1&n=10",
+			nil,
+			"Error: coeffs must be a list of 1 to 100 integers\n",
+			400,
+		},
+		{
+			"init-length-mismatch",
+			"coeffs=1,1&init=0&n=10",
+			nil,
+			"Error: init must be a list of as many integers as coeffs\n",
+			400,
+		},
+		{
+			"invalid-n",
+			"coeffs=1,1&init=0,1&n=-1",
+			nil,
+			"Error: n must be a non-negative integer less than 2^4096\n",
+			400,
+		},
+		{
+			"n-power-out-of-range",
+			"coeffs=1,1&init=0,1&n=2^5000&mod=7",
+			nil,
+			"Error: n must be a non-negative integer less than 2^4096\n",
+			400,
+		},
+		{
+			"invalid-mod",
+			"coeffs=1,1&init=0,1&n=10&mod=0",
+			nil,
+			"Error: mod must be a positive integer less than 2^4096\n",
+			400,
+		},
+		{
+			"mod-too-large",
+			"coeffs=1,1&init=0,1&n=10&mod=2^4096",
+			nil,
+			"Error: mod must be a positive integer less than 2^4096\n",
+			400,
+		},
+		{
+			"order-too-large",
+			"coeffs=" + strings.Repeat("1,", 99) + "1&init=" + strings.Repeat("0,", 99) + "1&n=16&mod=7",
+			nil,
+			"Error: the order of the recurrence is too large for n\n",
+			400,
+		},
+		{
+			"too-large-without-mod",
+			"coeffs=1,1&init=0,1&n=10^18",
+			nil,
+			"Error: the term is too large; set mod\n",
+			400,
+		},
+	}
+
+	h := queryApiMiddleware(handleRecurrence)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
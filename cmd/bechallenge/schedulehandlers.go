@@ -0,0 +1,396 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The most teams and legs in a schedule.
+	maxScheduleTeams = 1000
+	maxScheduleLegs  = 10
+
+	// The most teams in a schedule with constraints; placing them takes
+	// time cubic in their number (see roundRobin).
+	maxConstrainedTeams = 200
+)
+
+// A round-robin schedule, along with the constraints it doesn't meet.
+type schedule struct {
+	Rounds []round      `json:"rounds"`
+	Unmet  []constraint `json:"unmet"`
+}
+
+// A round of a schedule. Bye is the team that doesn't play, if the
+// number of teams is odd.
+type round struct {
+	Round    int       `json:"round"`
+	Fixtures []fixture `json:"fixtures"`
+	Bye      string    `json:"bye,omitempty"`
+}
+
+// A match of a schedule.
+type fixture struct {
+	Home string `json:"home"`
+	Away string `json:"away"`
+}
+
+// A schedule constraint: team `Team` hosts a match in round `Round` if
+// `Venue` is "home", or doesn't if it's "away".
+type constraint struct {
+	Team  string `json:"team"`
+	Round int    `json:"round"`
+	Venue string `json:"venue"`
+
+	// The index of the team.
+	team int
+}
+
+// Handles schedule requests by generating a round-robin schedule with
+// the circle method. The teams are either numbered from 1 to the value
+// of the `teams` query parameter, or the labels uploaded as the "file"
+// CSV form file (all the fields, in order).
+//
+// Query parameters:
+//   - legs: the number of times each pair of teams meet (1 by
+//     default), swapping home and away every leg
+//   - home, away: comma separated team:round constraints; a team away
+//     in a round doesn't host a match in it (it may have a bye); they
+//     limit the schedule to 200 teams
+//   - format: json (default), list (CSV lines of round,home,away) or
+//     matrix, where the (i, j) entry is the round in which team i hosts
+//     team j (0 if it doesn't); the matrix supports up to 2 legs
+//
+// Within a leg every team alternates home and away matches, except for
+// the n-2 breaks that can't be avoided. The teams are placed in the
+// slots of the circle so that as few constraints as possible are
+// violated; the violated ones are listed in the JSON response, and fail
+// the other formats. Only the placements in that one circle schedule
+// are searched, so a constraint can be reported as violated even if
+// some other schedule would meet it.
+func handleSchedule(w h.ResponseWriter, r *h.Request) {
+	legs := 1
+	if s := r.FormValue("legs"); s != "" {
+		var err error
+		if legs, err = strconv.Atoi(s); err != nil || legs < 1 || legs > maxScheduleLegs {
+			h.Error(w, fmt.Sprintf("Error: legs must be an integer between 1 and %d", maxScheduleLegs),
+				h.StatusBadRequest)
+
+			return
+		}
+	}
+
+	format := r.FormValue("format")
+	if format != "" && format != "json" && format != "list" && format != "matrix" {
+		h.Error(w, fmt.Sprintf("Error: unknown format %q", format), h.StatusBadRequest)
+
+		return
+	}
+	if format == "matrix" && legs > 2 {
+		h.Error(w, "Error: matrix format supports at most 2 legs", h.StatusBadRequest)
+
+		return
+	}
+
+	labels, ok := scheduleTeams(w, r)
+	if !ok {
+		return
+	}
+
+	rounds := legs * (len(labels) - 1 + len(labels)%2)
+
+	var constraints []constraint
+	for _, venue := range []string{"home", "away"} {
+		cs, err := parseConstraints(r.FormValue(venue), venue, labels, rounds)
+		if err != nil {
+			h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+			return
+		}
+		constraints = append(constraints, cs...)
+	}
+	if len(constraints) > 0 && len(labels) > maxConstrainedTeams {
+		h.Error(w, fmt.Sprintf("Error: a schedule with constraints can have at most %d teams", maxConstrainedTeams),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	hosts, unmet := roundRobin(len(labels), legs, constraints)
+
+	if format != "" && format != "json" && len(unmet) > 0 {
+		var cs []string
+		for _, c := range unmet {
+			cs = append(cs, fmt.Sprintf("%s %s in round %d", c.Team, c.Venue, c.Round))
+		}
+		h.Error(w, "Error: constraints can't be met by the circle method schedule: "+strings.Join(cs, ", "),
+			h.StatusBadRequest)
+
+		return
+	}
+
+	switch format {
+	case "matrix":
+		m := make([][]*big.Int, len(labels))
+		for i := range m {
+			m[i] = make([]*big.Int, len(labels))
+			for j := range m[i] {
+				m[i][j] = new(big.Int)
+			}
+		}
+		for k, fs := range hosts {
+			for _, f := range fs {
+				m[f[0]][f[1]].SetInt64(int64(k + 1))
+			}
+		}
+
+		fmt.Fprint(w, mtos(m))
+
+	case "list":
+		w.Header().Set("Content-Type", "text/csv")
+
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"round", "home", "away"})
+		for k, fs := range hosts {
+			for _, f := range fs {
+				cw.Write([]string{fmt.Sprint(k + 1), labels[f[0]], labels[f[1]]})
+			}
+		}
+
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			l.Error("writing CSV response", "err", err)
+		}
+
+	default:
+		res := schedule{Rounds: []round{}, Unmet: []constraint{}}
+		for k, fs := range hosts {
+			rd := round{Round: k + 1, Fixtures: []fixture{}}
+
+			plays := make([]bool, len(labels))
+			for _, f := range fs {
+				rd.Fixtures = append(rd.Fixtures, fixture{labels[f[0]], labels[f[1]]})
+				plays[f[0]], plays[f[1]] = true, true
+			}
+			for i, p := range plays {
+				if !p {
+					rd.Bye = labels[i]
+				}
+			}
+
+			res.Rounds = append(res.Rounds, rd)
+		}
+		res.Unmet = append(res.Unmet, unmet...)
+
+		writeJSON(w, res)
+	}
+}
+
+// Gets the team labels for a schedule request (see handleSchedule).
+// Reports errors to the user; the caller should return if `ok` is
+// false.
+func scheduleTeams(w h.ResponseWriter, r *h.Request) (labels []string, ok bool) {
+	tooMany := fmt.Sprintf("Error: a schedule can have at most %d teams", maxScheduleTeams)
+
+	if s := r.FormValue("teams"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 2 {
+			h.Error(w, "Error: teams must be an integer greater than 1", h.StatusBadRequest)
+
+			return nil, false
+		}
+		if n > maxScheduleTeams {
+			h.Error(w, tooMany, h.StatusBadRequest)
+
+			return nil, false
+		}
+
+		for i := range n {
+			labels = append(labels, fmt.Sprint(i+1))
+		}
+
+		return labels, true
+	}
+
+	recs, ok := formRecords(w, r, "file")
+	if !ok {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	for _, rec := range recs {
+		for _, s := range rec {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if seen[s] {
+				h.Error(w, fmt.Sprintf("Error: duplicate team %q", s), h.StatusBadRequest)
+
+				return nil, false
+			}
+
+			seen[s] = true
+			labels = append(labels, s)
+		}
+	}
+
+	if len(labels) < 2 {
+		h.Error(w, "Error: at least 2 teams expected", h.StatusBadRequest)
+
+		return nil, false
+	}
+	if len(labels) > maxScheduleTeams {
+		h.Error(w, tooMany, h.StatusBadRequest)
+
+		return nil, false
+	}
+
+	return labels, true
+}
+
+// Parses the comma separated team:round constraints in `s`.
+func parseConstraints(s, venue string, labels []string, rounds int) ([]constraint, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var cs []constraint
+	for _, c := range strings.Split(s, ",") {
+		// Split on the last colon; team labels may contain them.
+		k := strings.LastIndex(c, ":")
+		if k < 0 {
+			return nil, fmt.Errorf("%s: constraint %q must be of the form team:round", venue, c)
+		}
+
+		team := slices.Index(labels, strings.TrimSpace(c[:k]))
+		if team < 0 {
+			return nil, fmt.Errorf("%s: unknown team %q", venue, c[:k])
+		}
+
+		rd, err := strconv.Atoi(c[k+1:])
+		if err != nil || rd < 1 || rd > rounds {
+			return nil, fmt.Errorf("%s: round %s out of range (1-%d)", venue, c[k+1:], rounds)
+		}
+
+		cs = append(cs, constraint{labels[team], rd, venue, team})
+	}
+
+	return cs, nil
+}
+
+// Generates a round-robin schedule for `n` teams with the circle method
+// (see handleSchedule). Returns the (home, away) pairs of each round
+// and the constraints the schedule violates.
+func roundRobin(n, legs int, constraints []constraint) (hosts [][][2]int, unmet []constraint) {
+	// With an odd number of teams, the team playing the extra "slot"
+	// has a bye.
+	slots := n + n%2
+
+	// Slot `slots-1` stays put while the others rotate around the
+	// circle; the home side alternates along the circle so that every
+	// slot switches between home and away each round, except for one
+	// break.
+	var base [][][2]int
+	for r := 0; r < slots-1; r++ {
+		var fs [][2]int
+		if r%2 == 0 {
+			fs = append(fs, [2]int{r, slots - 1})
+		} else {
+			fs = append(fs, [2]int{slots - 1, r})
+		}
+
+		for k := 1; k < slots/2; k++ {
+			a, b := (r+k)%(slots-1), (r-k+slots-1)%(slots-1)
+			if k%2 == 1 {
+				fs = append(fs, [2]int{a, b})
+			} else {
+				fs = append(fs, [2]int{b, a})
+			}
+		}
+
+		base = append(base, fs)
+	}
+
+	for leg := 0; leg < legs; leg++ {
+		for _, fs := range base {
+			var rd [][2]int
+			for _, f := range fs {
+				if leg%2 == 1 {
+					f[0], f[1] = f[1], f[0]
+				}
+				rd = append(rd, f)
+			}
+
+			hosts = append(hosts, rd)
+		}
+	}
+
+	// Place the teams in the slots. Each constraint depends on the slot
+	// of one team only, so the placement violating the fewest of them is
+	// a solution of the assignment problem.
+	slotOf := make([]int, slots)
+	for i := range slotOf {
+		slotOf[i] = i
+	}
+
+	if len(constraints) > 0 {
+		hostsIn := make([][]bool, slots)
+		for s := range hostsIn {
+			hostsIn[s] = make([]bool, len(hosts))
+		}
+		for k, fs := range hosts {
+			for _, f := range fs {
+				hostsIn[f[0]][k] = true
+			}
+		}
+
+		cost := make([][]*big.Int, slots)
+		for t := range cost {
+			cost[t] = make([]*big.Int, slots)
+			for s := range cost[t] {
+				cost[t][s] = new(big.Int)
+			}
+		}
+		for _, c := range constraints {
+			for s := range slots {
+				if hostsIn[s][c.Round-1] != (c.Venue == "home") {
+					cost[c.team][s].Add(cost[c.team][s], big.NewInt(1))
+				}
+			}
+		}
+
+		slotOf = hungarian(cost)
+
+		for _, c := range constraints {
+			if hostsIn[slotOf[c.team]][c.Round-1] != (c.Venue == "home") {
+				unmet = append(unmet, c)
+			}
+		}
+	}
+
+	teamIn := make([]int, slots)
+	for t, s := range slotOf {
+		teamIn[s] = t
+	}
+
+	for i, fs := range hosts {
+		k := 0
+		for _, f := range fs {
+			a, b := teamIn[f[0]], teamIn[f[1]]
+			// Drop the matches against the dummy team.
+			if a < n && b < n {
+				fs[k] = [2]int{a, b}
+				k++
+			}
+		}
+		hosts[i] = fs[:k]
+	}
+
+	return hosts, unmet
+}
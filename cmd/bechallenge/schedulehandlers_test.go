@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHandleSchedule(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"matrix",
+			"teams=4&format=matrix",
+			nil,
+			"0,3,0,1\n0,0,1,0\n2,0,0,3\n0,2,0,0\n",
+			200,
+		},
+		{
+			"matrix-two-legs",
+			"teams=4&legs=2&format=matrix",
+			nil,
+			"0,3,5,1\n6,0,1,5\n2,4,0,3\n4,2,6,0\n",
+			200,
+		},
+		{
+			"list-labels",
+			"format=list",
+			[]byte("Ajax\nPSV\nFeyenoord\n"),
+			"round,home,away\n1,PSV,Feyenoord\n2,Feyenoord,Ajax\n3,Ajax,PSV\n",
+			200,
+		},
+		{
+			"json-bye",
+			"teams=3",
+			nil,
+			`{"rounds":[{"round":1,"fixtures":[{"home":"2","away":"3"}],"bye":"1"},` +
+				`{"round":2,"fixtures":[{"home":"3","away":"1"}],"bye":"2"},` +
+				`{"round":3,"fixtures":[{"home":"1","away":"2"}],"bye":"3"}],"unmet":[]}` + "\n",
+			200,
+		},
+		{
+			"constraints-met",
+			"teams=4&format=list&away=1:1,2:3&home=3:2",
+			nil,
+			"round,home,away\n1,4,3\n1,2,1\n2,3,2\n2,1,4\n3,1,3\n3,4,2\n",
+			200,
+		},
+		{
+			"constraints-unmet",
+			"teams=4&home=1:1,1:2",
+			nil,
+			`{"rounds":[{"round":1,"fixtures":[{"home":"1","away":"4"},{"home":"2","away":"3"}]},` +
+				`{"round":2,"fixtures":[{"home":"4","away":"2"},{"home":"3","away":"1"}]},` +
+				`{"round":3,"fixtures":[{"home":"3","away":"4"},{"home":"1","away":"2"}]}],` +
+				`"unmet":[{"team":"1","round":2,"venue":"home"}]}` + "\n",
+			200,
+		},
+		{
+			"constraints-unmet-list",
+			"teams=4&home=1:1,1:2&format=list",
+			nil,
+			"Error: constraints can't be met by the circle method schedule: 1 home in round 2\n",
+			400,
+		},
+		{
+			"unknown-team",
+			"teams=4&away=5:1",
+			nil,
+			"Error: away: unknown team \"5\"\n",
+			400,
+		},
+		{
+			"round-out-of-range",
+			"teams=4&legs=2&home=1:7",
+			nil,
+			"Error: home: round 7 out of range (1-6)\n",
+			400,
+		},
+		{
+			"too-many-constrained-teams",
+			"teams=201&home=1:1",
+			nil,
+			"Error: a schedule with constraints can have at most 200 teams\n",
+			400,
+		},
+		{
+			"invalid-constraint",
+			"teams=4&home=1",
+			nil,
+			"Error: home: constraint \"1\" must be of the form team:round\n",
+			400,
+		},
+		{
+			"too-few-teams",
+			"teams=1",
+			nil,
+			"Error: teams must be an integer greater than 1\n",
+			400,
+		},
+		{
+			"too-few-labels",
+			"",
+			[]byte("Ajax\n"),
+			"Error: at least 2 teams expected\n",
+			400,
+		},
+		{
+			"duplicate-label",
+			"",
+			[]byte("Ajax,PSV,Ajax\n"),
+			"Error: duplicate team \"Ajax\"\n",
+			400,
+		},
+		{
+			"invalid-legs",
+			"teams=4&legs=0",
+			nil,
+			"Error: legs must be an integer between 1 and 10\n",
+			400,
+		},
+		{
+			"matrix-too-many-legs",
+			"teams=4&legs=3&format=matrix",
+			nil,
+			"Error: matrix format supports at most 2 legs\n",
+			400,
+		},
+		{
+			"unknown-format",
+			"teams=4&format=xml",
+			nil,
+			"Error: unknown format \"xml\"\n",
+			400,
+		},
+		{
+			"huge-file",
+			"teams=4&legs=2&format=list",
+			make([]byte, maxUploadSize+1),
+			"Error: file upload size limit (10485760 bytes) exceeded\n",
+			400,
+		},
+	}
+
+	h := queryApiMiddleware(handleSchedule)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
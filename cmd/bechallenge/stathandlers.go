@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	h "net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Caps the number of variables of covariance and correlation
+	// requests, and of terms of regress requests.
+	maxStatVariables = 32
+
+	// Caps the work of building the covariance and normal equation
+	// matrices, the number of observations times the number of
+	// variables squared.
+	maxStatWork = 1 << 20
+)
+
+// Descriptive statistics of a group of matrix elements. Integers are
+// strings so that JSON clients don't lose precision; rationals are
+// exact fractions in lowest terms; the standard deviation is rounded
+// to the requested precision. The mode is omitted if all the elements
+// are distinct, and everything but the count for empty groups.
+type stats struct {
+	Group     string            `json:"group"`
+	Count     int               `json:"count"`
+	Mean      string            `json:"mean,omitempty"`
+	Median    string            `json:"median,omitempty"`
+	Mode      []string          `json:"mode,omitempty"`
+	Variance  string            `json:"variance,omitempty"`
+	StdDev    string            `json:"stddev,omitempty"`
+	Min       string            `json:"min,omitempty"`
+	Max       string            `json:"max,omitempty"`
+	Quantiles map[string]string `json:"quantiles,omitempty"`
+}
+
+// Handles stats requests by validating the supplied matrix of int
+// literals and returning its descriptive statistics. Expects the matrix
+// CSV in the request context.
+//
+// Query parameters:
+//   - axis: compute over the whole matrix (default) or per rows, cols,
+//     diag or antidiag (see reduce)
+//   - q: comma separated quantiles to compute, as decimals or
+//     fractions between 0 and 1 (e.g. q=0.25,1/2,0.75)
+//   - prec: significant decimal digits of the standard deviation
+//   - format: json (default) or csv
+//
+// The variance is the population variance. Quantiles are linearly
+// interpolated between the closest ranks.
+func handleStats(w h.ResponseWriter, r *h.Request) {
+	prec, err := parsePrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	qs, err := parseQuantiles(r.FormValue("q"))
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	format := r.FormValue("format")
+	if format != "" && format != "json" && format != "csv" {
+		h.Error(w, fmt.Sprintf("Error: unknown format %q", format), h.StatusBadRequest)
+
+		return
+	}
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	axis := r.FormValue("axis")
+	groups, err := axisGroups(m, axis)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	resp := make([]stats, len(groups))
+	for i, g := range groups {
+		resp[i] = groupStats(g, qs, prec)
+		resp[i].Group = groupLabel(axis, i)
+	}
+
+	if format == "csv" {
+		writeStatsCSV(w, resp, qs)
+	} else if axis == "" {
+		writeJSON(w, resp[0])
+	} else {
+		writeJSON(w, resp)
+	}
+}
+
+// Labels the i-th group of elements along `axis` for the stats output.
+func groupLabel(axis string, i int) string {
+	switch axis {
+	case "rows":
+		return fmt.Sprintf("row%d", i+1)
+	case "cols":
+		return fmt.Sprintf("col%d", i+1)
+	case "":
+		return "all"
+	}
+
+	return axis
+}
+
+// A quantile to compute, along with its label as given by the user.
+type quantile struct {
+	label string
+	q     *big.Rat
+}
+
+// Parses the comma separated list of quantiles `s`.
+func parseQuantiles(s string) ([]quantile, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var qs []quantile
+	for _, lit := range strings.Split(s, ",") {
+		lit = strings.TrimSpace(lit)
+
+		q, ok := new(big.Rat).SetString(lit)
+		if !ok || q.Sign() < 0 || q.Cmp(big.NewRat(1, 1)) > 0 {
+			return nil, fmt.Errorf("quantile %q must be a number between 0 and 1", lit)
+		}
+		if slices.ContainsFunc(qs, func(p quantile) bool { return p.q.Cmp(q) == 0 }) {
+			return nil, fmt.Errorf("quantile %q repeated", lit)
+		}
+
+		qs = append(qs, quantile{lit, q})
+	}
+
+	return qs, nil
+}
+
+// Computes the statistics of the group of elements `ds`.
+func groupStats(ds []*big.Int, qs []quantile, prec int) stats {
+	s := stats{Count: len(ds)}
+	if len(ds) == 0 {
+		return s
+	}
+
+	sorted := slices.Clone(ds)
+	slices.SortFunc(sorted, (*big.Int).Cmp)
+
+	n := big.NewInt(int64(len(ds)))
+
+	sum := new(big.Int)
+	for _, d := range ds {
+		sum.Add(sum, d)
+	}
+	mean := new(big.Rat).SetFrac(sum, n)
+
+	// Population variance: the mean of squared deviations.
+	variance := new(big.Rat)
+	for _, d := range ds {
+		dev := new(big.Rat).SetInt(d)
+		dev.Sub(dev, mean)
+		variance.Add(variance, dev.Mul(dev, dev))
+	}
+	variance.Quo(variance, new(big.Rat).SetInt(n))
+
+	s.Mean = mean.RatString()
+	s.Median = quantileOf(sorted, big.NewRat(1, 2)).RatString()
+	for _, d := range modeOf(sorted) {
+		s.Mode = append(s.Mode, d.String())
+	}
+	s.Variance = variance.RatString()
+	s.StdDev = sqrtRat(variance, prec)
+	s.Min = sorted[0].String()
+	s.Max = sorted[len(sorted)-1].String()
+
+	if len(qs) > 0 {
+		s.Quantiles = make(map[string]string, len(qs))
+		for _, q := range qs {
+			s.Quantiles[q.label] = quantileOf(sorted, q.q).RatString()
+		}
+	}
+
+	return s
+}
+
+// Returns the q-quantile of the non-empty sorted slice `sorted`,
+// linearly interpolated between the closest ranks.
+func quantileOf(sorted []*big.Int, q *big.Rat) *big.Rat {
+	// The (fractional) 0-based rank of the quantile.
+	rank := new(big.Rat).Mul(q, big.NewRat(int64(len(sorted)-1), 1))
+
+	lo := new(big.Int).Quo(rank.Num(), rank.Denom())
+	i := int(lo.Int64())
+
+	res := new(big.Rat).SetInt(sorted[i])
+	if i+1 < len(sorted) {
+		frac := new(big.Rat).Sub(rank, new(big.Rat).SetInt(lo))
+		diff := new(big.Rat).SetInt(new(big.Int).Sub(sorted[i+1], sorted[i]))
+		res.Add(res, diff.Mul(diff, frac))
+	}
+
+	return res
+}
+
+// Returns the most frequent values of the non-empty sorted slice
+// `sorted` in ascending order, or nil if they're all distinct.
+func modeOf(sorted []*big.Int) []*big.Int {
+	var mode []*big.Int
+	best := 0
+
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j].Cmp(sorted[i]) == 0 {
+			j++
+		}
+
+		if j-i > best {
+			best = j - i
+			mode = mode[:0]
+		}
+		if j-i == best {
+			mode = append(mode, sorted[i])
+		}
+
+		i = j
+	}
+
+	if best == 1 {
+		return nil
+	}
+
+	return mode
+}
+
+// Writes the statistics as a CSV table with a header row and a row
+// per group.
+func writeStatsCSV(w h.ResponseWriter, ss []stats, qs []quantile) {
+	w.Header().Set("Content-Type", "text/csv")
+
+	header := []string{
+		"group", "count", "mean", "median", "mode", "variance", "stddev", "min", "max",
+	}
+	for _, q := range qs {
+		header = append(header, "q"+q.label)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+
+	for _, s := range ss {
+		rec := []string{s.Group, fmt.Sprint(s.Count), s.Mean, s.Median,
+			strings.Join(s.Mode, " "), s.Variance, s.StdDev, s.Min, s.Max}
+		for _, q := range qs {
+			rec = append(rec, s.Quantiles[q.label])
+		}
+
+		cw.Write(rec)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		l.Error("writing CSV response", "err", err)
+	}
+}
+
+// Handles covariance requests by treating the columns of the supplied
+// matrix of int literals as variables (and its rows as observations)
+// and returning their sample covariance matrix. The results are exact
+// fractions unless the `prec` query parameter asks for decimals. The
+// size of the matrix is limited by checkStatSize. Expects the matrix
+// CSV in the request context.
+func handleCovariance(w h.ResponseWriter, r *h.Request) {
+	prec, err := parseOptPrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	cov, ok := covarianceMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	fmt.Fprint(w, rmtos(cov, prec))
+}
+
+// Handles correlation requests by treating the columns of the supplied
+// matrix of int literals as variables and returning their Pearson
+// correlation matrix with `prec` significant digits. The size of the
+// matrix is limited by checkStatSize. Expects the matrix CSV in the
+// request context.
+func handleCorrelation(w h.ResponseWriter, r *h.Request) {
+	prec, err := parsePrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	cov, ok := covarianceMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	bits := precBits(prec)
+
+	// Standard deviations of the variables.
+	sd := make([]*big.Float, len(cov))
+	for i := range cov {
+		if cov[i][i].Sign() == 0 {
+			h.Error(w, fmt.Sprintf("Error: column %d has zero variance", i+1), h.StatusBadRequest)
+
+			return
+		}
+
+		sd[i] = new(big.Float).SetPrec(bits).SetRat(cov[i][i])
+		sd[i].Sqrt(sd[i])
+	}
+
+	var resp string
+	for i, row := range cov {
+		ss := make([]string, len(row))
+		for j, c := range row {
+			f := new(big.Float).SetPrec(bits).SetRat(c)
+			f.Quo(f, sd[i])
+			f.Quo(f, sd[j])
+			ss[j] = ftos(f, prec)
+		}
+
+		resp += strings.Join(ss, ",") + "\n"
+	}
+
+	// The challenge spec requires a trailing "\n" in the response.
+	if len(resp) == 0 {
+		resp += "\n"
+	}
+
+	fmt.Fprint(w, resp)
+}
+
+// Rejects `rows` observations of `vars` variables (or regression
+// terms) if there are more than maxStatVariables variables or the work
+// would exceed maxStatWork. Reports errors to the user; the caller
+// should return if the result is false.
+func checkStatSize(w h.ResponseWriter, rows, vars int) bool {
+	if vars > maxStatVariables {
+		h.Error(w, fmt.Sprintf("Error: at most %d variables are supported", maxStatVariables), h.StatusBadRequest)
+
+		return false
+	}
+	if rows*vars*vars > maxStatWork {
+		h.Error(w, fmt.Sprintf("Error: too many observations for %d variables", vars), h.StatusBadRequest)
+
+		return false
+	}
+
+	return true
+}
+
+// Parses the matrix in the request context and computes the sample
+// covariance matrix of its columns. Reports errors to the user; the
+// caller should return if `ok` is false.
+func covarianceMatrix(w h.ResponseWriter, r *h.Request) (cov [][]*big.Rat, ok bool) {
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if len(m) < 2 {
+		h.Error(w, "Error: at least 2 observations (rows) required", h.StatusBadRequest)
+
+		return nil, false
+	}
+	if !checkStatSize(w, len(m), ncols(m)) {
+		return nil, false
+	}
+
+	// Center the columns.
+	x := ratMatrix(m)
+	for j := range x[0] {
+		mean := new(big.Rat)
+		for i := range x {
+			mean.Add(mean, x[i][j])
+		}
+		mean.Quo(mean, big.NewRat(int64(len(x)), 1))
+
+		for i := range x {
+			x[i][j].Sub(x[i][j], mean)
+		}
+	}
+
+	cov = mulRat(transposeRat(x), x)
+
+	n1 := big.NewRat(int64(len(x)-1), 1)
+	for _, row := range cov {
+		for _, c := range row {
+			c.Quo(c, n1)
+		}
+	}
+
+	return cov, true
+}
+
+// A least squares fit of one column against the others.
+type regression struct {
+	Coefficients []coefficient `json:"coefficients"`
+	Residuals    []string      `json:"residuals"`
+	// Omitted if the response variable is constant.
+	R2 string `json:"r2,omitempty"`
+}
+
+// A fitted coefficient along with the term it belongs to: either
+// "intercept" or the predictor's column (col1, col2, ...).
+type coefficient struct {
+	Term  string `json:"term"`
+	Value string `json:"value"`
+}
+
+// Handles regress requests by fitting the column of the supplied
+// matrix of int literals selected by the `y` query parameter (1-based)
+// against the other columns with ordinary least squares. Returns the
+// coefficients, residuals and R² as JSON. The number of terms and
+// observations is limited by checkStatSize. Expects the matrix CSV in
+// the request context.
+//
+// The normal equations are solved exactly over the rationals; the
+// results are exact fractions unless the `prec` query parameter asks
+// for decimals. Set `intercept=false` to fit without an intercept.
+func handleRegress(w h.ResponseWriter, r *h.Request) {
+	prec, err := parseOptPrec(r)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
+
+		return
+	}
+
+	intercept := r.FormValue("intercept") != "false"
+
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	y, err := strconv.Atoi(r.FormValue("y"))
+	if err != nil || y < 1 || y > ncols(m) {
+		h.Error(w, "Error: y must be a column number between 1 and the number of columns",
+			h.StatusBadRequest)
+
+		return
+	}
+	y--
+
+	// Build the design matrix and the response vector.
+	var terms []string
+	if intercept {
+		terms = append(terms, "intercept")
+	}
+	for j := 0; j < ncols(m); j++ {
+		if j != y {
+			terms = append(terms, fmt.Sprintf("col%d", j+1))
+		}
+	}
+	if len(terms) == 0 {
+		h.Error(w, "Error: no predictors; add columns or an intercept", h.StatusBadRequest)
+
+		return
+	}
+	if !checkStatSize(w, len(m), len(terms)) {
+		return
+	}
+
+	x := newRatMatrix(len(m), len(terms))
+	ys := newRatMatrix(len(m), 1)
+	for i, row := range m {
+		k := 0
+		if intercept {
+			x[i][k].SetInt64(1)
+			k++
+		}
+
+		for j, d := range row {
+			if j == y {
+				ys[i][0].SetInt(d)
+			} else {
+				x[i][k].SetInt(d)
+				k++
+			}
+		}
+	}
+
+	// Solve the normal equations (XᵀX)β = Xᵀy.
+	xt := transposeRat(x)
+	beta, err := solveRat(mulRat(xt, x), mulRat(xt, ys))
+	if err != nil {
+		h.Error(w, "Error: predictors are linearly dependent or too few observations",
+			h.StatusBadRequest)
+
+		return
+	}
+
+	resp := regression{}
+	for k, t := range terms {
+		resp.Coefficients = append(resp.Coefficients, coefficient{t, rtos(beta[k][0], prec)})
+	}
+
+	// Residuals and the sums of squares for R².
+	fit := mulRat(x, beta)
+	mean := new(big.Rat)
+	for i := range ys {
+		mean.Add(mean, ys[i][0])
+	}
+	mean.Quo(mean, big.NewRat(int64(len(ys)), 1))
+
+	ssRes, ssTot := new(big.Rat), new(big.Rat)
+	for i := range ys {
+		res := new(big.Rat).Sub(ys[i][0], fit[i][0])
+		resp.Residuals = append(resp.Residuals, rtos(res, prec))
+		ssRes.Add(ssRes, res.Mul(res, res))
+
+		dev := new(big.Rat).Sub(ys[i][0], mean)
+		ssTot.Add(ssTot, dev.Mul(dev, dev))
+	}
+
+	if ssTot.Sign() != 0 {
+		r2 := new(big.Rat).Quo(ssRes, ssTot)
+		resp.R2 = rtos(r2.Sub(big.NewRat(1, 1), r2), prec)
+	}
+
+	writeJSON(w, resp)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHandleStats(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"smoke-test",
+			"",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			`{"group":"all","count":9,"mean":"5","median":"5",` +
+				`"variance":"20/3","stddev":"2.5819888974716112568","min":"1","max":"9"}` + "\n",
+			200,
+		},
+		{
+			"quantiles",
+			"q=0,1/4,0.5,1&prec=5",
+			[]byte("1,2\n2,10"),
+			`{"group":"all","count":4,"mean":"15/4","median":"2","mode":["2"],` +
+				`"variance":"211/16","stddev":"3.6315","min":"1","max":"10",` +
+				`"quantiles":{"0":"1","0.5":"2","1":"10","1/4":"7/4"}}` + "\n",
+			200,
+		},
+		{
+			"axis-cols",
+			"axis=cols&prec=3",
+			[]byte("1,2\n4,2"),
+			`[{"group":"col1","count":2,"mean":"5/2","median":"5/2",` +
+				`"variance":"9/4","stddev":"1.5","min":"1","max":"4"},` +
+				`{"group":"col2","count":2,"mean":"2","median":"2","mode":["2"],` +
+				`"variance":"0","stddev":"0","min":"2","max":"2"}]` + "\n",
+			200,
+		},
+		{
+			"csv",
+			"axis=rows&format=csv&q=0.5&prec=3",
+			[]byte("1,2\n3,3"),
+			"group,count,mean,median,mode,variance,stddev,min,max,q0.5\n" +
+				"row1,2,3/2,3/2,,1/4,0.5,1,2,3/2\n" +
+				"row2,2,3,3,3,0,0,3,3,3\n",
+			200,
+		},
+		{
+			"large-integers",
+			"prec=5",
+			[]byte("12345678901234567890"),
+			`{"group":"all","count":1,"mean":"12345678901234567890","median":"12345678901234567890",` +
+				`"variance":"0","stddev":"0",` +
+				`"min":"12345678901234567890","max":"12345678901234567890"}` + "\n",
+			200,
+		},
+		{
+			"empty-csv",
+			"",
+			[]byte{},
+			`{"group":"all","count":0}` + "\n",
+			200,
+		},
+		{
+			"invalid-quantile",
+			"q=1.5",
+			[]byte("1"),
+			"Error: quantile \"1.5\" must be a number between 0 and 1\n",
+			400,
+		},
+		{
+			"repeated-quantile",
+			"q=0.5,1/2",
+			[]byte("1"),
+			"Error: quantile \"1/2\" repeated\n",
+			400,
+		},
+		{
+			"invalid-prec",
+			"prec=0",
+			[]byte("1"),
+			"Error: prec must be an integer between 1 and 1000\n",
+			400,
+		},
+		{
+			"unknown-format",
+			"format=xml",
+			[]byte("1"),
+			"Error: unknown format \"xml\"\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := entrywiseApiMiddleware(handleStats)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleCovariance(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"smoke-test",
+			"",
+			[]byte("1,2\n2,4\n3,7"),
+			"1,5/2\n5/2,19/3\n",
+			200,
+		},
+		{
+			"decimals",
+			"prec=4",
+			[]byte("1,2\n2,4\n3,7"),
+			"1,2.5\n2.5,6.333\n",
+			200,
+		},
+		{
+			"large-integers",
+			"",
+			[]byte("12345678901234567890\n-12345678901234567890"),
+			"304831575064776735003810399750038104200\n",
+			200,
+		},
+		{
+			"too-few-observations",
+			"",
+			[]byte("1,2,3"),
+			"Error: at least 2 observations (rows) required\n",
+			400,
+		},
+		{
+			"too-many-variables",
+			"",
+			[]byte(strings.Repeat("1,", 32) + "1\n" + strings.Repeat("2,", 32) + "2"),
+			"Error: at most 32 variables are supported\n",
+			400,
+		},
+		{
+			"too-many-observations",
+			"",
+			[]byte(strings.Repeat("1,2,3,4,5,6,7,8\n", 16385)),
+			"Error: too many observations for 8 variables\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			"",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleCovariance)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleCorrelation(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"smoke-test",
+			"prec=5",
+			[]byte("1,2,3\n2,4,1\n3,6,2"),
+			"1,1,-0.5\n1,1,-0.5\n-0.5,-0.5,1\n",
+			200,
+		},
+		{
+			"irrational",
+			"prec=6",
+			[]byte("1,2\n2,4\n3,7"),
+			"1,0.993399\n0.993399,1\n",
+			200,
+		},
+		{
+			"zero-variance",
+			"",
+			[]byte("1,5\n2,5"),
+			"Error: column 2 has zero variance\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleCorrelation)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleRegress(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"exact-fit",
+			"y=2",
+			[]byte("1,3\n2,5\n3,7"),
+			`{"coefficients":[{"term":"intercept","value":"1"},{"term":"col1","value":"2"}],` +
+				`"residuals":["0","0","0"],"r2":"1"}` + "\n",
+			200,
+		},
+		{
+			"least-squares",
+			"y=1",
+			[]byte("1,1\n2,2\n2,3"),
+			`{"coefficients":[{"term":"intercept","value":"2/3"},{"term":"col2","value":"1/2"}],` +
+				`"residuals":["-1/6","1/3","-1/6"],"r2":"3/4"}` + "\n",
+			200,
+		},
+		{
+			"decimals",
+			"y=1&prec=3",
+			[]byte("1,1\n2,2\n2,3"),
+			`{"coefficients":[{"term":"intercept","value":"0.667"},{"term":"col2","value":"0.5"}],` +
+				`"residuals":["-0.167","0.333","-0.167"],"r2":"0.75"}` + "\n",
+			200,
+		},
+		{
+			"no-intercept",
+			"y=2&intercept=false",
+			[]byte("1,2\n2,5"),
+			`{"coefficients":[{"term":"col1","value":"12/5"}],` +
+				`"residuals":["-2/5","1/5"],"r2":"43/45"}` + "\n",
+			200,
+		},
+		{
+			"constant-response",
+			"y=2",
+			[]byte("1,4\n2,4"),
+			`{"coefficients":[{"term":"intercept","value":"4"},{"term":"col1","value":"0"}],` +
+				`"residuals":["0","0"]}` + "\n",
+			200,
+		},
+		{
+			"dependent-predictors",
+			"y=3",
+			[]byte("1,2,1\n2,4,2\n3,6,4"),
+			"Error: predictors are linearly dependent or too few observations\n",
+			400,
+		},
+		{
+			"no-predictors",
+			"y=1&intercept=false",
+			[]byte("1\n2"),
+			"Error: no predictors; add columns or an intercept\n",
+			400,
+		},
+		{
+			"too-many-terms",
+			"y=1",
+			[]byte(strings.Repeat("1,", 32) + "1\n" + strings.Repeat("2,", 32) + "2"),
+			"Error: at most 32 variables are supported\n",
+			400,
+		},
+		{
+			"invalid-y",
+			"y=3",
+			[]byte("1,2\n2,4"),
+			"Error: y must be a column number between 1 and the number of columns\n",
+			400,
+		},
+	}
+
+	h := rectApiMiddleware(handleRegress)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
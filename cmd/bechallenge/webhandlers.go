@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	h "net/http"
+	"strings"
 )
 
 // Handles echo requests by validating the parsed matrix of int
@@ -24,58 +25,238 @@ func handleFlatten(w h.ResponseWriter, r *h.Request) {
 // literals and returning a string with their sum. Expects the matrix
 // CSV in the request context.
 func handleSum(w h.ResponseWriter, r *h.Request) {
-	reduce(w, r, false)
+	reduce(w, r, reduceSum)
 }
 
 // Handles multiply requests by validating the supplied matrix of int
 // literals and returning a string with their product. Expects the
 // matrix CSV in the request context.
 func handleMultiply(w h.ResponseWriter, r *h.Request) {
-	reduce(w, r, true)
+	reduce(w, r, reduceProduct)
 }
 
-// Implements the actual handler for reduce-like (sum, multiply)
-// requests.
-func reduce(w h.ResponseWriter, r *h.Request, multiply bool) {
+// Handles min requests by validating the supplied matrix of int
+// literals and returning a string with the smallest one. Expects the
+// matrix CSV in the request context.
+func handleMin(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceMin)
+}
+
+// Handles max requests by validating the supplied matrix of int
+// literals and returning a string with the largest one. Expects the
+// matrix CSV in the request context.
+func handleMax(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceMax)
+}
+
+// Handles gcd requests by validating the supplied matrix of int
+// literals and returning a string with their greatest common
+// divisor. Expects the matrix CSV in the request context.
+func handleGcd(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceGcd)
+}
+
+// Handles lcm requests by validating the supplied matrix of int
+// literals and returning a string with their least common
+// multiple. Expects the matrix CSV in the request context.
+func handleLcm(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceLcm)
+}
+
+// Handles count-nonzero requests by validating the supplied matrix of
+// int literals and returning a string with the number of non-zero
+// ones. Expects the matrix CSV in the request context.
+func handleCountNonzero(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceCountNonzero)
+}
+
+// Handles mean requests by validating the supplied matrix of int
+// literals and returning a string with their exact (rational)
+// mean. Expects the matrix CSV in the request context.
+func handleMean(w h.ResponseWriter, r *h.Request) {
+	reduce(w, r, reduceMean)
+}
+
+// Implements the actual handler for reduce-like (sum, multiply, etc.)
+// requests. By default the whole matrix is reduced to one value. The
+// `axis` query parameter selects a vector of per-row (rows) or
+// per-column (cols) results, or the reduction of the main (diag) or
+// anti- (antidiag) diagonal.
+func reduce(w h.ResponseWriter, r *h.Request, fn reduceFunc) {
 	recs := r.Context().Value(csvRecordsKey).([][]string)
+	axis := r.FormValue("axis")
 
 	// Handle zero size matrix edge case.
-	if len(recs) == 0 {
+	if len(recs) == 0 && axis == "" {
 		fmt.Fprint(w, 0, "\n")
 
 		return
 	}
 
-	resp := new(big.Int)
+	m, ok := parseMatrix(w, r)
+	if !ok {
+		return
+	}
+
+	groups, err := axisGroups(m, axis)
+	if err != nil {
+		h.Error(w, "Error: "+err.Error(), h.StatusBadRequest)
 
-	// Zero value of int would turn every product to 0.
-	if multiply {
-		resp.SetInt64(1)
+		return
 	}
 
-	// Process the CSV rows and build the response inline.
-	for ri, row := range recs {
-		ints, err := atoi(row)
-		if err != nil {
-			h.Error(
-				w,
-				fmt.Sprintf("Error: parsing CSV: record on line %d: %v", ri+1, err),
-				h.StatusBadRequest)
+	resp := make([]string, len(groups))
+	for i, g := range groups {
+		resp[i] = fn(g)
+	}
 
-			return
-		}
+	// The challenge spec requires a trailing "\n" in the response.
+	fmt.Fprint(w, strings.Join(resp, ","), "\n")
+}
 
-		for _, int := range ints {
-			if multiply {
-				resp.Mul(resp, int)
-			} else {
-				resp.Add(resp, int)
+// Splits the matrix `m` into the groups of elements that lie along
+// `axis` (see reduce). An empty axis means the whole matrix.
+func axisGroups(m [][]*big.Int, axis string) ([][]*big.Int, error) {
+	var groups [][]*big.Int
+
+	switch axis {
+	case "":
+		var all []*big.Int
+		for _, row := range m {
+			all = append(all, row...)
+		}
+		groups = append(groups, all)
+	case "rows":
+		groups = m
+	case "cols":
+		if len(m) > 0 {
+			groups = make([][]*big.Int, len(m[0]))
+		}
+		for _, row := range m {
+			for ci, d := range row {
+				groups[ci] = append(groups[ci], d)
+			}
+		}
+	case "diag", "antidiag":
+		var diag []*big.Int
+		for ri, row := range m {
+			ci := ri
+			if axis == "antidiag" {
+				ci = len(row) - 1 - ri
 			}
+			if ci < 0 || ci >= len(row) {
+				break
+			}
+			diag = append(diag, row[ci])
 		}
+		groups = append(groups, diag)
+	default:
+		return nil, fmt.Errorf("unknown axis %q", axis)
 	}
 
-	// The challenge spec requires a trailing "\n" in the response.
-	fmt.Fprint(w, resp, "\n")
+	return groups, nil
+}
+
+// Reduces the group of matrix elements `ds` to a single value.
+type reduceFunc func(ds []*big.Int) string
+
+// Sums the elements.
+func reduceSum(ds []*big.Int) string {
+	s := new(big.Int)
+	for _, d := range ds {
+		s.Add(s, d)
+	}
+
+	return s.String()
+}
+
+// Multiplies the elements.
+func reduceProduct(ds []*big.Int) string {
+	p := big.NewInt(1)
+	for _, d := range ds {
+		p.Mul(p, d)
+	}
+
+	return p.String()
+}
+
+// Picks the smallest element.
+func reduceMin(ds []*big.Int) string {
+	if len(ds) == 0 {
+		return "0"
+	}
+
+	m := ds[0]
+	for _, d := range ds[1:] {
+		if d.Cmp(m) < 0 {
+			m = d
+		}
+	}
+
+	return m.String()
+}
+
+// Picks the largest element.
+func reduceMax(ds []*big.Int) string {
+	if len(ds) == 0 {
+		return "0"
+	}
+
+	m := ds[0]
+	for _, d := range ds[1:] {
+		if d.Cmp(m) > 0 {
+			m = d
+		}
+	}
+
+	return m.String()
+}
+
+// Computes the (non-negative) greatest common divisor of the elements.
+func reduceGcd(ds []*big.Int) string {
+	g := new(big.Int)
+	for _, d := range ds {
+		gcd(g, g, d)
+	}
+
+	return g.String()
+}
+
+// Computes the (non-negative) least common multiple of the elements.
+func reduceLcm(ds []*big.Int) string {
+	m := big.NewInt(1)
+	for _, d := range ds {
+		lcm(m, m, d)
+	}
+
+	return m.String()
+}
+
+// Counts the non-zero elements.
+func reduceCountNonzero(ds []*big.Int) string {
+	var n int
+	for _, d := range ds {
+		if d.Sign() != 0 {
+			n++
+		}
+	}
+
+	return fmt.Sprint(n)
+}
+
+// Computes the mean of the elements. Returns a fraction in lowest
+// terms, or an integer literal if the mean is whole.
+func reduceMean(ds []*big.Int) string {
+	if len(ds) == 0 {
+		return "0"
+	}
+
+	s := new(big.Int)
+	for _, d := range ds {
+		s.Add(s, d)
+	}
+
+	return new(big.Rat).SetFrac(s, big.NewInt(int64(len(ds)))).RatString()
 }
 
 // Implements the actual handler for echo-like (echo, flatten)
@@ -83,7 +264,7 @@ func reduce(w h.ResponseWriter, r *h.Request, multiply bool) {
 func echo(w h.ResponseWriter, r *h.Request, flatten bool) {
 	recs := r.Context().Value(csvRecordsKey).([][]string)
 
-	var resp string
+	var resp strings.Builder
 
 	// Process the CSV rows and build the response inline.
 	for ri, row := range recs {
@@ -97,22 +278,20 @@ func echo(w h.ResponseWriter, r *h.Request, flatten bool) {
 			return
 		}
 
-		resp += itos(ints)
-		if flatten {
-			resp += ","
-		} else {
-			resp += "\n"
+		if flatten && resp.Len() > 0 {
+			resp.WriteByte(',')
+		}
+		writeInts(&resp, ints)
+		if !flatten {
+			resp.WriteByte('\n')
 		}
 	}
 
-	if len(resp) == 0 {
-		// The challenge spec requires a trailing "\n" in the response.
-		resp += "\n"
-	} else if flatten {
-		// Replace last comma with a new line
-		resp = resp[:len(resp)-1] + "\n"
+	// The challenge spec requires a trailing "\n" in the response.
+	if resp.Len() == 0 || flatten {
+		resp.WriteByte('\n')
 	}
-	fmt.Fprint(w, resp)
+	fmt.Fprint(w, resp.String())
 }
 
 // Handles invert requests by validating the supplied matrix of int
@@ -122,7 +301,10 @@ func handleInvert(w h.ResponseWriter, r *h.Request) {
 	recs := r.Context().Value(csvRecordsKey).([][]string)
 
 	// Transposed matrix.
-	tran := make([][]*big.Int, len(recs))
+	var tran [][]*big.Int
+	if len(recs) > 0 {
+		tran = make([][]*big.Int, len(recs[0]))
+	}
 
 	// Process the CSV rows.
 	for ri, row := range recs {
@@ -142,16 +324,5 @@ func handleInvert(w h.ResponseWriter, r *h.Request) {
 		}
 	}
 
-	// Build the response.
-	var resp string
-	for _, ints := range tran {
-		resp += itos(ints) + "\n"
-	}
-
-	// The challenge spec requires a trailing "\n" in the response.
-	if len(resp) == 0 {
-		resp += "\n"
-	}
-
-	fmt.Fprint(w, resp)
+	fmt.Fprint(w, mtos(tran))
 }
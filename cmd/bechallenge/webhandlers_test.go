@@ -1,6 +1,7 @@
 package main
 
 import (
+	"net/http"
 	"testing"
 )
 
@@ -81,7 +82,7 @@ func TestHandleEcho(t *testing.T) {
 		},
 	}
 
-	h := webApiMiddleware(handleEcho)
+	h := entrywiseApiMiddleware(handleEcho)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -166,7 +167,7 @@ func TestHandleFlatten(t *testing.T) {
 		},
 	}
 
-	h := webApiMiddleware(handleFlatten)
+	h := entrywiseApiMiddleware(handleFlatten)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -257,7 +258,7 @@ func TestHandleSum(t *testing.T) {
 		},
 	}
 
-	h := webApiMiddleware(handleSum)
+	h := entrywiseApiMiddleware(handleSum)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -348,7 +349,7 @@ func TestHandleMultiply(t *testing.T) {
 		},
 	}
 
-	h := webApiMiddleware(handleMultiply)
+	h := entrywiseApiMiddleware(handleMultiply)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -433,7 +434,7 @@ func TestHandleInvert(t *testing.T) {
 		},
 	}
 
-	h := webApiMiddleware(handleInvert)
+	h := entrywiseApiMiddleware(handleInvert)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -441,3 +442,233 @@ func TestHandleInvert(t *testing.T) {
 		})
 	}
 }
+
+func TestReduceAxis(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(http.ResponseWriter, *http.Request)
+		query      string
+		payload    []byte
+		wantBody   string
+		wantStatus int
+	}{
+		{
+			"sum-rows",
+			handleSum,
+			"axis=rows",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"6,15,24\n",
+			200,
+		},
+		{
+			"sum-cols",
+			handleSum,
+			"axis=cols",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"12,15,18\n",
+			200,
+		},
+		{
+			"sum-diag",
+			handleSum,
+			"axis=diag",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"15\n",
+			200,
+		},
+		{
+			"sum-antidiag",
+			handleSum,
+			"axis=antidiag",
+			[]byte("1,2,3\n4,5,6\n7,8,10"),
+			"15\n",
+			200,
+		},
+		{
+			"multiply-rows",
+			handleMultiply,
+			"axis=rows",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"6,120,504\n",
+			200,
+		},
+		{
+			"multiply-cols",
+			handleMultiply,
+			"axis=cols",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"28,80,162\n",
+			200,
+		},
+		{
+			"min",
+			handleMin,
+			"",
+			[]byte("3,-2\n5,4"),
+			"-2\n",
+			200,
+		},
+		{
+			"min-cols",
+			handleMin,
+			"axis=cols",
+			[]byte("3,-2\n5,4"),
+			"3,-2\n",
+			200,
+		},
+		{
+			"max",
+			handleMax,
+			"",
+			[]byte("3,-2\n5,4"),
+			"5\n",
+			200,
+		},
+		{
+			"max-rows",
+			handleMax,
+			"axis=rows",
+			[]byte("3,-2\n5,4"),
+			"3,5\n",
+			200,
+		},
+		{
+			"gcd",
+			handleGcd,
+			"",
+			[]byte("12,-18\n30,0"),
+			"6\n",
+			200,
+		},
+		{
+			"gcd-rows",
+			handleGcd,
+			"axis=rows",
+			[]byte("12,-18\n30,0"),
+			"6,30\n",
+			200,
+		},
+		{
+			"lcm",
+			handleLcm,
+			"",
+			[]byte("4,-6\n3,2"),
+			"12\n",
+			200,
+		},
+		{
+			"lcm-with-zero",
+			handleLcm,
+			"axis=rows",
+			[]byte("4,-6\n0,2"),
+			"12,0\n",
+			200,
+		},
+		{
+			"count-nonzero",
+			handleCountNonzero,
+			"",
+			[]byte("1,0\n0,-3"),
+			"2\n",
+			200,
+		},
+		{
+			"count-nonzero-cols",
+			handleCountNonzero,
+			"axis=cols",
+			[]byte("1,0\n0,0"),
+			"1,0\n",
+			200,
+		},
+		{
+			"mean",
+			handleMean,
+			"",
+			[]byte("1,2\n3,4"),
+			"5/2\n",
+			200,
+		},
+		{
+			"mean-rows",
+			handleMean,
+			"axis=rows",
+			[]byte("1,2\n3,5"),
+			"3/2,4\n",
+			200,
+		},
+		{
+			"large-integers",
+			handleMax,
+			"axis=diag",
+			[]byte("-12345678901234567890,0\n0,12345678901234567890"),
+			"12345678901234567890\n",
+			200,
+		},
+		{
+			"empty-csv",
+			handleMin,
+			"",
+			[]byte{},
+			"0\n",
+			200,
+		},
+		{
+			"empty-csv-rows",
+			handleSum,
+			"axis=rows",
+			[]byte{},
+			"\n",
+			200,
+		},
+		{
+			"unknown-axis",
+			handleSum,
+			"axis=depth",
+			[]byte("1"),
+			"Error: unknown axis \"depth\"\n",
+			400,
+		},
+		{
+			"non-integer-literals",
+			handleSum,
+			"axis=rows",
+			[]byte("1, 2, -3.234\n1,-2.121,3\n1.983,2,-3\n"),
+			"Error: parsing CSV: record on line 1: parsing \"-3.234\": invalid syntax\n",
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := entrywiseApiMiddleware(tt.handler)
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}
+
+func TestHandleTranspose(t *testing.T) {
+	tests := []queryTestCase{
+		{
+			"non-square",
+			"",
+			[]byte("1,2,3\n4,5,6"),
+			"1,4\n2,5\n3,6\n",
+			200,
+		},
+		{
+			"sliced",
+			"cols=1:2",
+			[]byte("1,2,3\n4,5,6\n7,8,9"),
+			"1,4,7\n2,5,8\n",
+			200,
+		},
+	}
+
+	h := rectApiMiddleware(handleInvert)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runQueryTestCase(t, h, tt.query, tt.payload, tt.wantBody, tt.wantStatus)
+		})
+	}
+}